@@ -1,14 +1,23 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
 
+	"eshopbuilder/internal/auth"
 	"eshopbuilder/internal/config"
 	"eshopbuilder/internal/database"
 	"eshopbuilder/internal/handlers"
 	"eshopbuilder/internal/middleware"
+	"eshopbuilder/internal/rbac"
+	"eshopbuilder/internal/stats"
+	"eshopbuilder/internal/traffic"
 
 	"github.com/go-chi/chi/v5"
 	chiMiddleware "github.com/go-chi/chi/v5/middleware"
@@ -31,8 +40,40 @@ func main() {
 		log.Printf("Migration warning: %v", err)
 	}
 
+	// Stop on Ctrl+C / SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The counters outlive the server so requests finishing during shutdown are flushed too
+	counterCtx, stopCounter := context.WithCancel(context.Background())
+	flushInterval := time.Duration(cfg.StatsFlushInterval) * time.Second
+	counter := stats.NewCounter(db, flushInterval)
+	filtered := traffic.NewRecorder(db, flushInterval)
+	var counters sync.WaitGroup
+	counters.Add(2)
+	go func() {
+		defer counters.Done()
+		counter.Run(counterCtx)
+	}()
+	go func() {
+		defer counters.Done()
+		filtered.Run(counterCtx)
+	}()
+
+	// Role permissions, checked on every admin route
+	roles := rbac.NewStore(db)
+	can := func(permission string) func(http.Handler) http.Handler {
+		return middleware.RequirePermission(roles, permission)
+	}
+
+	// Login sessions; access tokens are short-lived and refreshed with rotating refresh tokens
+	sessions := auth.NewSessions(db, time.Duration(cfg.RefreshTokenTTLDays)*24*time.Hour)
+
+	// Scoped keys for scripts and integrations (X-API-Key)
+	apiKeys := auth.NewAPIKeys(db, roles, cfg.TrustProxy, cfg.TrustRealIP)
+
 	// Create handler
-	h := handlers.New(db, cfg)
+	h := handlers.New(db, cfg, counter, filtered, roles, sessions, apiKeys)
 
 	// Setup router
 	r := chi.NewRouter()
@@ -44,8 +85,8 @@ func main() {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-API-Key"},
+		ExposedHeaders:   []string{"Link", "X-Total-Count", "X-Search-ID"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -56,62 +97,141 @@ func main() {
 		r.Post("/auth/login", h.Login)
 		r.Post("/auth/register", h.Register)
 		r.Post("/auth/refresh", h.RefreshToken)
+		r.Get("/auth/invitation", h.GetInvitation)
+		r.Post("/auth/accept-invite", h.AcceptInvitation)
 
 		// Public routes
 		r.Get("/products", h.ListProducts)
 		r.Get("/products/{slug}", h.GetProduct)
+		r.Get("/products/{slug}/price-history", h.GetPriceHistory)
 		r.Get("/categories", h.ListCategories)
 		r.Get("/categories/{slug}", h.GetCategory)
+		r.Get("/categories/{slug}/products", h.GetCategoryProducts)
 		r.Get("/search", h.SearchProducts)
+		r.Get("/search/suggest", h.SearchSuggest)
+		r.Post("/search/click", h.SearchClick)
 
 		// Protected routes
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.AuthMiddleware(cfg.JWTSecret))
+			r.Use(middleware.AuthMiddleware(cfg.JWTSecret, sessions, apiKeys))
+
+			// Profile (user logins only, not API keys)
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.RequireUser)
+
+				r.Post("/auth/logout", h.Logout)
+				r.Post("/auth/logout-all", h.LogoutAll)
+
+				r.Get("/me", h.GetMe)
+				r.Put("/me", h.UpdateMe)
+				r.Put("/me/password", h.ChangePassword)
+				r.Get("/me/sessions", h.ListSessions)
+				r.Delete("/me/sessions/{id}", h.RevokeSession)
+			})
 
 			// Admin
 			r.Route("/admin", func(r chi.Router) {
 				// Dashboard
-				r.Get("/stats", h.GetDashboardStats)
-				r.Get("/recent-activity", h.GetRecentActivity)
+				r.With(can(rbac.DashboardRead)).Get("/stats", h.GetDashboardStats)
+				r.With(can(rbac.DashboardRead)).Get("/recent-activity", h.GetRecentActivity)
+				r.With(can(rbac.DashboardRead)).Get("/stats/timeseries", h.GetStatsTimeseries)
+				r.With(can(rbac.DashboardRead)).Get("/stats/top", h.GetStatsTop)
+				r.With(can(rbac.DashboardRead)).Get("/stats/feed-health", h.GetFeedHealth)
 
 				// Products
-				r.Get("/products", h.AdminListProducts)
-				r.Post("/products", h.CreateProduct)
-				r.Get("/products/{id}", h.AdminGetProduct)
-				r.Put("/products/{id}", h.UpdateProduct)
-				r.Delete("/products/{id}", h.DeleteProduct)
-				r.Post("/products/bulk-action", h.BulkProductAction)
+				r.With(can(rbac.ProductsRead)).Get("/products", h.AdminListProducts)
+				r.With(can(rbac.ProductsWrite)).Post("/products", h.CreateProduct)
+				r.With(can(rbac.ProductsRead)).Get("/products/{id}", h.AdminGetProduct)
+				r.With(can(rbac.ProductsWrite)).Put("/products/{id}", h.UpdateProduct)
+				r.With(can(rbac.ProductsWrite)).Put("/products/{id}/locked-fields", h.UpdateLockedFields)
+				r.With(can(rbac.ProductsRead)).Get("/products/{id}/stats", h.GetProductStats)
+				r.With(can(rbac.ProductsWrite)).Delete("/products/{id}", h.DeleteProduct)
+				r.With(can(rbac.ProductsWrite)).Post("/products/bulk-action", h.BulkProductAction)
 
 				// Categories
-				r.Get("/categories", h.AdminListCategories)
-				r.Post("/categories", h.CreateCategory)
-				r.Put("/categories/{id}", h.UpdateCategory)
-				r.Delete("/categories/{id}", h.DeleteCategory)
+				r.With(can(rbac.CategoriesRead)).Get("/categories", h.AdminListCategories)
+				r.With(can(rbac.CategoriesWrite)).Post("/categories", h.CreateCategory)
+				r.With(can(rbac.CategoriesWrite)).Put("/categories/{id}", h.UpdateCategory)
+				r.With(can(rbac.CategoriesWrite)).Delete("/categories/{id}", h.DeleteCategory)
+
+				// Redirects
+				r.With(can(rbac.RedirectsRead)).Get("/redirects", h.ListRedirects)
+				r.With(can(rbac.RedirectsWrite)).Post("/redirects", h.CreateRedirect)
+				r.With(can(rbac.RedirectsWrite)).Put("/redirects/{id}", h.UpdateRedirect)
+				r.With(can(rbac.RedirectsWrite)).Delete("/redirects/{id}", h.DeleteRedirect)
+
+				// Search analytics
+				r.With(can(rbac.SearchRead)).Get("/search/top-queries", h.GetTopSearchQueries)
+				r.With(can(rbac.SearchRead)).Get("/search/zero-results", h.GetZeroResultQueries)
+				r.With(can(rbac.SearchRead)).Get("/search/synonyms", h.ListSynonyms)
+				r.With(can(rbac.SearchWrite)).Post("/search/synonyms", h.CreateSynonym)
+				r.With(can(rbac.SearchWrite)).Put("/search/synonyms/{id}", h.UpdateSynonym)
+				r.With(can(rbac.SearchWrite)).Delete("/search/synonyms/{id}", h.DeleteSynonym)
+				r.With(can(rbac.SearchRead)).Get("/search/stopwords", h.ListStopwords)
+				r.With(can(rbac.SearchWrite)).Post("/search/stopwords", h.CreateStopwords)
+				r.With(can(rbac.SearchWrite)).Delete("/search/stopwords/{word}", h.DeleteStopword)
 
 				// Feeds
-				r.Get("/feeds", h.ListFeeds)
-				r.Post("/feeds", h.CreateFeed)
-				r.Get("/feeds/{id}", h.GetFeed)
-				r.Put("/feeds/{id}", h.UpdateFeed)
-				r.Delete("/feeds/{id}", h.DeleteFeed)
-				r.Post("/feeds/{id}/import", h.StartImport)
-				r.Post("/feeds/{id}/stop", h.StopImport)
-				r.Get("/feeds/{id}/progress", h.GetImportProgress)
-				r.Get("/feeds/{id}/history", h.GetImportHistory)
-				r.Post("/feeds/preview", h.PreviewFeed)
-				r.Post("/feeds/auto-mapping", h.AutoMapping)
+				r.With(can(rbac.FeedsRead)).Get("/feeds", h.ListFeeds)
+				r.With(can(rbac.FeedsWrite)).Post("/feeds", h.CreateFeed)
+				r.With(can(rbac.FeedsRead)).Get("/feeds/{id}", h.GetFeed)
+				r.With(can(rbac.FeedsWrite)).Put("/feeds/{id}", h.UpdateFeed)
+				r.With(can(rbac.FeedsWrite)).Delete("/feeds/{id}", h.DeleteFeed)
+				r.With(can(rbac.FeedsImport)).Post("/feeds/{id}/import", h.StartImport)
+				r.With(can(rbac.FeedsImport)).Post("/feeds/{id}/stop", h.StopImport)
+				r.With(can(rbac.FeedsRead)).Get("/feeds/{id}/progress", h.GetImportProgress)
+				r.With(can(rbac.FeedsRead)).Get("/feeds/{id}/history", h.GetImportHistory)
+				r.With(can(rbac.FeedsWrite)).Post("/feeds/preview", h.PreviewFeed)
+				r.With(can(rbac.FeedsWrite)).Post("/feeds/auto-mapping", h.AutoMapping)
+				r.With(can(rbac.FeedsRead)).Get("/affiliate-networks", h.ListAffiliateNetworks)
+
+				// Conversions
+				r.With(can(rbac.ReportsRead)).Get("/conversions", h.ListConversions)
+				r.With(can(rbac.ReportsRead)).Get("/reports/conversions", h.GetConversionReport)
+				r.With(can(rbac.ReportsRead)).Get("/traffic/filtered", h.GetFilteredTraffic)
 
 				// Settings
-				r.Get("/settings", h.GetSettings)
-				r.Put("/settings", h.UpdateSettings)
+				r.With(can(rbac.SettingsRead)).Get("/settings", h.GetSettings)
+				r.With(can(rbac.SettingsWrite)).Put("/settings", h.UpdateSettings)
 
 				// Shop config
-				r.Get("/shop-config", h.GetShopConfig)
-				r.Put("/shop-config", h.UpdateShopConfig)
+				r.With(can(rbac.SettingsRead)).Get("/shop-config", h.GetShopConfig)
+				r.With(can(rbac.SettingsWrite)).Put("/shop-config", h.UpdateShopConfig)
+
+				// Roles
+				r.With(can(rbac.RolesManage)).Get("/permissions", h.ListPermissions)
+				r.With(can(rbac.RolesManage)).Get("/roles", h.ListRoles)
+				r.With(can(rbac.RolesManage)).Post("/roles", h.CreateRole)
+				r.With(can(rbac.RolesManage)).Put("/roles/{name}", h.UpdateRole)
+				r.With(can(rbac.RolesManage)).Delete("/roles/{name}", h.DeleteRole)
+
+				// Users
+				r.With(can(rbac.UsersManage)).Get("/users", h.ListUsers)
+				r.With(can(rbac.UsersManage)).Post("/users", h.CreateUser)
+				r.With(can(rbac.UsersManage)).Get("/users/{id}", h.GetUser)
+				r.With(can(rbac.UsersManage)).Put("/users/{id}", h.UpdateUser)
+				r.With(can(rbac.UsersManage)).Delete("/users/{id}", h.DeleteUser)
+				r.With(can(rbac.UsersManage)).Put("/users/{id}/role", h.UpdateUserRole)
+				r.With(can(rbac.UsersManage)).Post("/users/{id}/reset-password", h.ResetUserPassword)
+				r.With(can(rbac.UsersManage)).Get("/invitations", h.ListInvitations)
+				r.With(can(rbac.UsersManage)).Post("/invitations", h.CreateInvitation)
+				r.With(can(rbac.UsersManage)).Delete("/invitations/{id}", h.DeleteInvitation)
+
+				// API keys
+				r.With(can(rbac.APIKeysManage)).Get("/api-keys", h.ListAPIKeys)
+				r.With(can(rbac.APIKeysManage)).Post("/api-keys", h.CreateAPIKey)
+				r.With(can(rbac.APIKeysManage)).Delete("/api-keys/{id}", h.RevokeAPIKey)
 			})
 		})
 	})
 
+	// Affiliate click-out
+	r.Get("/go/{slug}", h.ClickOut)
+
+	// Conversion postbacks from affiliate networks
+	r.Get("/postback", h.Postback)
+	r.Post("/postback", h.Postback)
+
 	// Health check
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte("OK"))
@@ -123,6 +243,23 @@ func main() {
 		port = "8080"
 	}
 
-	log.Printf("🚀 EshopBuilder v3 API starting on :%s", port)
-	log.Fatal(http.ListenAndServe(":"+port, r))
+	server := &http.Server{Addr: ":" + port, Handler: r}
+	go func() {
+		log.Printf("🚀 EshopBuilder v3 API starting on :%s", port)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+
+	// Graceful shutdown: finish requests, then flush buffered counters
+	<-ctx.Done()
+	log.Println("Shutting down...")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Shutdown error: %v", err)
+	}
+	stopCounter()
+	counters.Wait()
 }
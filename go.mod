@@ -10,4 +10,5 @@ require (
 	github.com/jackc/pgx/v5 v5.5.2
 	golang.org/x/crypto v0.18.0
 	golang.org/x/net v0.20.0
+	golang.org/x/text v0.14.0
 )
@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"eshopbuilder/internal/rbac"
+	"eshopbuilder/internal/traffic"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// APIKeyPrefix - začiatok každého kľúča, aby sa dal ľahko rozpoznať (napr. pri úniku)
+const APIKeyPrefix = "ebk_"
+
+// apiKeyCacheTTL - kľúč zrušený na inej inštancii prestane platiť najneskôr po tomto čase
+const apiKeyCacheTTL = 30 * time.Second
+
+// touchInterval - last_used_at sa zapisuje najviac raz za tento čas
+const touchInterval = time.Minute
+
+var (
+	ErrInvalidAPIKey = errors.New("invalid, expired or revoked API key")
+	ErrIPNotAllowed  = errors.New("API key is not allowed from this IP")
+)
+
+// APIKey - Overený kľúč s oprávneniami
+type APIKey struct {
+	ID         string
+	Name       string
+	Scopes     []string
+	AllowedIPs []string
+	ExpiresAt  *time.Time
+}
+
+// APIKeys - Overovanie API kľúčov s krátkou cache
+type APIKeys struct {
+	db          *pgxpool.Pool
+	roles       *rbac.Store
+	trustProxy  bool
+	trustRealIP bool
+
+	mu        sync.Mutex
+	cache     map[string]apiKeyState // key hash -> state
+	lastSweep time.Time
+}
+
+type apiKeyState struct {
+	key     *APIKey // nil = unknown or revoked
+	checked time.Time
+	touched time.Time
+}
+
+// NewAPIKeys - roles obmedzujú scopes kľúča na aktuálne oprávnenia jeho autora
+func NewAPIKeys(db *pgxpool.Pool, roles *rbac.Store, trustProxy, trustRealIP bool) *APIKeys {
+	return &APIKeys{
+		db:          db,
+		roles:       roles,
+		trustProxy:  trustProxy,
+		trustRealIP: trustRealIP,
+		cache:       make(map[string]apiKeyState),
+		lastSweep:   time.Now(),
+	}
+}
+
+// GenerateAPIKey vráti nový kľúč, jeho zobraziteľný prefix a hash na uloženie
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	token, _, err := NewToken()
+	if err != nil {
+		return "", "", "", err
+	}
+	key = APIKeyPrefix + token
+	return key, key[:len(APIKeyPrefix)+8], HashToken(key), nil
+}
+
+// Authenticate overí kľúč z požiadavky vrátane expirácie a povolených IP
+func (k *APIKeys) Authenticate(r *http.Request, key string) (*APIKey, error) {
+	if !strings.HasPrefix(key, APIKeyPrefix) {
+		return nil, ErrInvalidAPIKey
+	}
+	ctx := r.Context()
+	hash := HashToken(key)
+	now := time.Now()
+
+	k.mu.Lock()
+	k.sweep(now)
+	state, cached := k.cache[hash]
+	k.mu.Unlock()
+
+	if !cached || now.Sub(state.checked) >= apiKeyCacheTTL {
+		found, err := k.load(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		state.key = found
+		state.checked = now
+	}
+	if state.key == nil || (state.key.ExpiresAt != nil && now.After(*state.key.ExpiresAt)) {
+		k.store(hash, state)
+		return nil, ErrInvalidAPIKey
+	}
+
+	ip := traffic.ClientIP(r, k.trustProxy, k.trustRealIP)
+	if !IPAllowed(ip, state.key.AllowedIPs) {
+		k.store(hash, state)
+		return nil, ErrIPNotAllowed
+	}
+
+	if now.Sub(state.touched) >= touchInterval {
+		k.db.Exec(ctx, "UPDATE api_keys SET last_used_at = NOW(), last_used_ip = $2 WHERE id = $1", state.key.ID, ip)
+		state.touched = now
+	}
+	k.store(hash, state)
+	return state.key, nil
+}
+
+// Invalidate zahodí cache (po zrušení kľúča, zmene autora alebo jeho roly)
+func (k *APIKeys) Invalidate() {
+	k.mu.Lock()
+	k.cache = make(map[string]apiKeyState)
+	k.mu.Unlock()
+}
+
+// load - kľúč platí len kým je jeho autor aktívny a má len scopes, ktoré autor
+// stále smie; zmazaný autor (created_by NULL) kľúč zneplatní
+func (k *APIKeys) load(ctx context.Context, hash string) (*APIKey, error) {
+	var key APIKey
+	var role string
+	var active bool
+	err := k.db.QueryRow(ctx, `
+		SELECT k.id, k.name, k.scopes, k.allowed_ips, k.expires_at, u.role, u.is_active
+		FROM api_keys k
+		JOIN users u ON u.id = k.created_by
+		WHERE k.key_hash = $1 AND k.revoked_at IS NULL
+	`, hash).Scan(&key.ID, &key.Name, &key.Scopes, &key.AllowedIPs, &key.ExpiresAt, &role, &active)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, nil
+	}
+
+	granted, err := k.roles.Permissions(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	scopes := key.Scopes[:0]
+	for _, scope := range key.Scopes {
+		if rbac.Allows(granted, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+	key.Scopes = scopes
+	return &key, nil
+}
+
+func (k *APIKeys) store(hash string, state apiKeyState) {
+	k.mu.Lock()
+	k.cache[hash] = state
+	k.mu.Unlock()
+}
+
+// sweep raz za minútu zahodí staré záznamy cache (volá sa pod zámkom)
+func (k *APIKeys) sweep(now time.Time) {
+	if now.Sub(k.lastSweep) < time.Minute {
+		return
+	}
+	k.lastSweep = now
+	for hash, state := range k.cache {
+		if now.Sub(state.checked) >= apiKeyCacheTTL {
+			delete(k.cache, hash)
+		}
+	}
+}
+
+// IPAllowed - prázdny zoznam povolí všetko; položky sú IP alebo CIDR
+func IPAllowed(ip string, allowed []string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, entry := range allowed {
+		if network, err := ParseIPNet(entry); err == nil && network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseIPNet - CIDR alebo samostatná IP (ako /32, resp. /128)
+func ParseIPNet(entry string) (*net.IPNet, error) {
+	entry = strings.TrimSpace(entry)
+	if !strings.Contains(entry, "/") {
+		if strings.Contains(entry, ":") {
+			entry += "/128"
+		} else {
+			entry += "/32"
+		}
+	}
+	_, network, err := net.ParseCIDR(entry)
+	return network, err
+}
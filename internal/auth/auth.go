@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// MinPasswordLength - Minimálna dĺžka hesla
+const MinPasswordLength = 8
+
+var ErrWeakPassword = errors.New("password must be at least 8 characters")
+
+// ValidatePassword overí požiadavky na heslo
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return ErrWeakPassword
+	}
+	return nil
+}
+
+// HashPassword - bcrypt hash hesla
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// CheckPassword porovná heslo s bcrypt hashom
+func CheckPassword(hash, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+// NewToken vygeneruje náhodný token a jeho hash na uloženie do DB
+func NewToken() (token, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = hex.EncodeToString(buf)
+	return token, HashToken(token), nil
+}
+
+// HashToken - SHA-256 tokenu (hex); tokeny sú náhodné, netreba pomalý hash
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
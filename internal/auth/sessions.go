@@ -0,0 +1,256 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Dôvody zrušenia relácie
+const (
+	RevokedLogout         = "logout"
+	RevokedLogoutAll      = "logout_all"
+	RevokedTokenReuse     = "token_reuse"
+	RevokedPasswordChange = "password_change"
+	RevokedDeactivated    = "deactivated"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid or expired refresh token")
+	ErrTokenReused  = errors.New("refresh token was already used, session revoked")
+)
+
+// sessionCacheTTL - ako dlho AuthMiddleware verí výsledku kontroly relácie;
+// zrušenie na inej inštancii sa prejaví najneskôr po tomto čase
+const sessionCacheTTL = 30 * time.Second
+
+// usedTokenRetention - použité refresh tokeny sa držia kvôli detekcii opakovaného použitia
+const usedTokenRetention = 7 * 24 * time.Hour
+
+// Sessions - Prihlásenia s rotovanými refresh tokenmi
+type Sessions struct {
+	db  *pgxpool.Pool
+	ttl time.Duration
+
+	mu        sync.Mutex
+	cache     map[string]sessionState
+	lastSweep time.Time
+}
+
+type sessionState struct {
+	userID  string
+	role    string
+	valid   bool
+	checked time.Time
+}
+
+// NewSessions - ttl je neaktivita, po ktorej refresh token prestane platiť
+func NewSessions(db *pgxpool.Pool, ttl time.Duration) *Sessions {
+	return &Sessions{
+		db:        db,
+		ttl:       ttl,
+		cache:     make(map[string]sessionState),
+		lastSweep: time.Now(),
+	}
+}
+
+// Create založí reláciu a vráti jej ID a prvý refresh token
+func (s *Sessions) Create(ctx context.Context, userID, userAgent, ip string) (sessionID, token string, err error) {
+	token, hash, err := NewToken()
+	if err != nil {
+		return "", "", err
+	}
+	if len(userAgent) > 500 {
+		userAgent = strings.ToValidUTF8(userAgent[:500], "")
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	defer tx.Rollback(ctx)
+
+	// Expired and long-revoked sessions of the user are pruned on each login
+	_, err = tx.Exec(ctx, `
+		DELETE FROM sessions
+		WHERE user_id = $1 AND (expires_at < NOW() OR revoked_at < NOW() - INTERVAL '30 days')
+	`, userID)
+	if err != nil {
+		return "", "", err
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO sessions (user_id, user_agent, ip, expires_at)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), $4)
+		RETURNING id
+	`, userID, userAgent, ip, time.Now().Add(s.ttl)).Scan(&sessionID)
+	if err != nil {
+		return "", "", err
+	}
+
+	if _, err := tx.Exec(ctx, "INSERT INTO refresh_tokens (token_hash, session_id) VALUES ($1, $2)", hash, sessionID); err != nil {
+		return "", "", err
+	}
+
+	return sessionID, token, tx.Commit(ctx)
+}
+
+// Rotate vymení refresh token za nový a predĺži reláciu. Opätovné použitie
+// už vymeneného tokenu znamená jeho únik - celá relácia sa zruší (ErrTokenReused)
+func (s *Sessions) Rotate(ctx context.Context, token string) (sessionID, userID, newToken string, err error) {
+	newToken, newHash, err := NewToken()
+	if err != nil {
+		return "", "", "", err
+	}
+
+	tx, err := s.db.Begin(ctx)
+	if err != nil {
+		return "", "", "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var usedAt *time.Time
+	var revoked bool
+	var expiresAt time.Time
+	err = tx.QueryRow(ctx, `
+		SELECT t.session_id, s.user_id, t.used_at, s.revoked_at IS NOT NULL, s.expires_at
+		FROM refresh_tokens t
+		JOIN sessions s ON s.id = t.session_id
+		WHERE t.token_hash = $1
+		FOR UPDATE OF t, s
+	`, HashToken(token)).Scan(&sessionID, &userID, &usedAt, &revoked, &expiresAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", "", "", ErrInvalidToken
+	}
+	if err != nil {
+		return "", "", "", err
+	}
+	if revoked || time.Now().After(expiresAt) {
+		return "", "", "", ErrInvalidToken
+	}
+
+	if usedAt != nil {
+		_, err := tx.Exec(ctx, `
+			UPDATE sessions SET revoked_at = NOW(), revoked_reason = $2 WHERE id = $1
+		`, sessionID, RevokedTokenReuse)
+		if err != nil {
+			return "", "", "", err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return "", "", "", err
+		}
+		s.forget(func(id string, _ sessionState) bool { return id == sessionID })
+		return "", "", "", ErrTokenReused
+	}
+
+	if _, err := tx.Exec(ctx, "UPDATE refresh_tokens SET used_at = NOW() WHERE token_hash = $1", HashToken(token)); err != nil {
+		return "", "", "", err
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO refresh_tokens (token_hash, session_id) VALUES ($1, $2)", newHash, sessionID); err != nil {
+		return "", "", "", err
+	}
+	_, err = tx.Exec(ctx, `
+		DELETE FROM refresh_tokens WHERE session_id = $1 AND used_at < $2
+	`, sessionID, time.Now().Add(-usedTokenRetention))
+	if err != nil {
+		return "", "", "", err
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE sessions SET last_used_at = NOW(), expires_at = $2 WHERE id = $1
+	`, sessionID, time.Now().Add(s.ttl))
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return sessionID, userID, newToken, tx.Commit(ctx)
+}
+
+// Revoke zruší jednu reláciu používateľa
+func (s *Sessions) Revoke(ctx context.Context, userID, sessionID, reason string) (bool, error) {
+	result, err := s.db.Exec(ctx, `
+		UPDATE sessions SET revoked_at = NOW(), revoked_reason = $3
+		WHERE id::text = $1 AND user_id::text = $2 AND revoked_at IS NULL
+	`, sessionID, userID, reason)
+	if err != nil {
+		return false, err
+	}
+	s.forget(func(id string, _ sessionState) bool { return id == sessionID })
+	return result.RowsAffected() > 0, nil
+}
+
+// RevokeUser zruší všetky relácie používateľa okrem except (napr. aktuálnej)
+func (s *Sessions) RevokeUser(ctx context.Context, userID, except, reason string) error {
+	_, err := s.db.Exec(ctx, `
+		UPDATE sessions SET revoked_at = NOW(), revoked_reason = $3
+		WHERE user_id::text = $1 AND id::text <> $2 AND revoked_at IS NULL
+	`, userID, except, reason)
+	if err != nil {
+		return err
+	}
+	s.Forget(userID)
+	return nil
+}
+
+// Forget zahodí cache relácií používateľa (po zmene roly, deaktivácii, zmazaní)
+func (s *Sessions) Forget(userID string) {
+	s.forget(func(_ string, state sessionState) bool { return state.userID == userID })
+}
+
+// Check overí, že relácia platí a používateľ je aktívny; vráti aktuálnu rolu
+// z databázy, takže zmena roly sa prejaví bez nového prihlásenia
+func (s *Sessions) Check(ctx context.Context, sessionID string) (userID, role string, ok bool, err error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	s.sweep(now)
+	state, cached := s.cache[sessionID]
+	s.mu.Unlock()
+	if cached && now.Sub(state.checked) < sessionCacheTTL {
+		return state.userID, state.role, state.valid, nil
+	}
+
+	state = sessionState{checked: now}
+	err = s.db.QueryRow(ctx, `
+		SELECT s.user_id, u.role
+		FROM sessions s
+		JOIN users u ON u.id = s.user_id
+		WHERE s.id::text = $1 AND s.revoked_at IS NULL AND s.expires_at > NOW() AND u.is_active = true
+	`, sessionID).Scan(&state.userID, &state.role)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return "", "", false, err
+	}
+	state.valid = err == nil
+
+	s.mu.Lock()
+	s.cache[sessionID] = state
+	s.mu.Unlock()
+	return state.userID, state.role, state.valid, nil
+}
+
+func (s *Sessions) forget(match func(id string, state sessionState) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, state := range s.cache {
+		if match(id, state) {
+			delete(s.cache, id)
+		}
+	}
+}
+
+// sweep raz za minútu zahodí staré záznamy cache (volá sa pod zámkom)
+func (s *Sessions) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+	for id, state := range s.cache {
+		if now.Sub(state.checked) >= sessionCacheTTL {
+			delete(s.cache, id)
+		}
+	}
+}
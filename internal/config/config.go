@@ -1,12 +1,52 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+)
 
 type Config struct {
 	DatabaseURL string
 	JWTSecret   string
 	Port        string
 	Environment string
+
+	// Import limits (per-feed settings override these)
+	FeedMaxBytes           int64
+	FeedMaxItemDropPercent float64
+
+	// Days of price history to keep, 0 = forever
+	PriceHistoryRetentionDays int
+
+	// Query parameters appended to every click-out URL, e.g. "utm_source=eshop&utm_medium=affiliate"
+	// (per-feed click_params override these)
+	ClickParams string
+
+	// Conversion postbacks are accepted with ?token= / X-Postback-Token or an HMAC-SHA256
+	// signature (X-Signature); with neither set the endpoint is disabled
+	PostbackToken  string
+	PostbackSecret string
+
+	// Traffic filtering for view and click counters
+	TrafficBotAgents       []string // extra user agent fragments treated as bots
+	TrafficIgnoredIPs      []string // IPs / CIDRs never counted (office, monitoring)
+	TrafficMaxPerMinute    int      // views + clicks per IP and minute, 0 = unlimited
+	TrafficDuplicateWindow int      // seconds in which a repeated view/click of a product is ignored
+	TrustProxy             bool     // client IP from the last X-Forwarded-For hop (one reverse proxy)
+	TrustRealIP            bool     // client IP from X-Real-IP, only if the proxy always sets it
+
+	// Seconds between batched writes of view / click counters
+	StatsFlushInterval int
+
+	// Public /auth/register; when off, users join by invitation only
+	AllowRegistration bool
+	// Hours an invitation link stays valid
+	InviteTTLHours int
+
+	// Access JWT lifetime in minutes; refresh tokens expire after this many days without use
+	AccessTokenTTL      int
+	RefreshTokenTTLDays int
 }
 
 func Load() *Config {
@@ -15,6 +55,31 @@ func Load() *Config {
 		JWTSecret:   getEnv("JWT_SECRET", "super-secret-jwt-key-change-in-production"),
 		Port:        getEnv("PORT", "8080"),
 		Environment: getEnv("ENVIRONMENT", "development"),
+
+		FeedMaxBytes:           int64(getEnvInt("FEED_MAX_BYTES", 500*1024*1024)),
+		FeedMaxItemDropPercent: getEnvFloat("FEED_MAX_ITEM_DROP_PERCENT", 30),
+
+		PriceHistoryRetentionDays: getEnvInt("PRICE_HISTORY_RETENTION_DAYS", 365),
+
+		ClickParams: getEnv("CLICK_PARAMS", "utm_source=eshopbuilder&utm_medium=affiliate"),
+
+		PostbackToken:  getEnv("POSTBACK_TOKEN", ""),
+		PostbackSecret: getEnv("POSTBACK_SECRET", ""),
+
+		TrafficBotAgents:       getEnvList("TRAFFIC_BOT_AGENTS"),
+		TrafficIgnoredIPs:      getEnvList("TRAFFIC_IGNORED_IPS"),
+		TrafficMaxPerMinute:    getEnvInt("TRAFFIC_MAX_PER_MINUTE", 30),
+		TrafficDuplicateWindow: getEnvInt("TRAFFIC_DUPLICATE_WINDOW", 60),
+		TrustProxy:             getEnv("TRUST_PROXY", "false") == "true",
+		TrustRealIP:            getEnv("TRUST_REAL_IP", "false") == "true",
+
+		StatsFlushInterval: getEnvInt("STATS_FLUSH_INTERVAL", 30),
+
+		AllowRegistration: getEnv("ALLOW_REGISTRATION", "true") == "true",
+		InviteTTLHours:    getEnvInt("INVITE_TTL_HOURS", 72),
+
+		AccessTokenTTL:      getEnvInt("ACCESS_TOKEN_TTL", 15),
+		RefreshTokenTTLDays: getEnvInt("REFRESH_TOKEN_TTL_DAYS", 30),
 	}
 }
 
@@ -24,3 +89,28 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, err := strconv.Atoi(os.Getenv(key)); err == nil {
+		return value
+	}
+	return defaultValue
+}
+
+// getEnvList - hodnoty oddelené čiarkou
+func getEnvList(key string) []string {
+	list := []string{}
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, err := strconv.ParseFloat(os.Getenv(key), 64); err == nil {
+		return value
+	}
+	return defaultValue
+}
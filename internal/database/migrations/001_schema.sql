@@ -173,8 +173,8 @@ CREATE TABLE IF NOT EXISTS shop_config (
 
 -- Insert default config
 INSERT INTO shop_config (shop_name, template, primary_color, secondary_color)
-VALUES ('EshopBuilder Store', 'aurora', '#3B82F6', '#10B981')
-ON CONFLICT DO NOTHING;
+SELECT 'EshopBuilder Store', 'aurora', '#3B82F6', '#10B981'
+WHERE NOT EXISTS (SELECT 1 FROM shop_config LIMIT 1);
 
 -- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
 -- DEFAULT ADMIN USER
@@ -0,0 +1,12 @@
+-- EshopBuilder v3 - Import safety checks
+-- ================================
+
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+-- IMPORT HISTORY
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+-- Run parsed noticeably fewer items than previous runs (truncated / partial feed)
+ALTER TABLE import_history ADD COLUMN IF NOT EXISTS suspicious BOOLEAN DEFAULT false;
+ALTER TABLE import_history ADD COLUMN IF NOT EXISTS suspicious_reason TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_import_history_feed_started ON import_history(feed_id, started_at DESC);
@@ -0,0 +1,5 @@
+-- EshopBuilder v3 - Feed item filters
+-- ================================
+
+-- Items dropped by per-feed filter rules (feeds.settings.filters)
+ALTER TABLE import_history ADD COLUMN IF NOT EXISTS filtered INTEGER DEFAULT 0;
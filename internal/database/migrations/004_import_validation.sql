@@ -0,0 +1,6 @@
+-- EshopBuilder v3 - Import validation rules
+-- ================================
+
+-- Per-rule failure counts ("rule:field" -> count) for feeds.settings.validation
+ALTER TABLE import_history ADD COLUMN IF NOT EXISTS warnings INTEGER DEFAULT 0;
+ALTER TABLE import_history ADD COLUMN IF NOT EXISTS validation_counts JSONB DEFAULT '{}'::jsonb;
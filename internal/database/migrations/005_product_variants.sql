@@ -0,0 +1,41 @@
+-- EshopBuilder v3 - Product variants
+-- ================================
+
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+-- PRODUCTS
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+-- Parent products of a variant group (ITEMGROUP_ID / item_group_id)
+ALTER TABLE products ADD COLUMN IF NOT EXISTS item_group_id VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_products_item_group ON products(feed_id, item_group_id);
+
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+-- PRODUCT VARIANTS
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+CREATE TABLE IF NOT EXISTS product_variants (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    variant_key VARCHAR(255) NOT NULL, -- external_id, sku, ean or title of the feed item
+    title VARCHAR(500) NOT NULL,
+    price DECIMAL(12,2) NOT NULL DEFAULT 0,
+    regular_price DECIMAL(12,2),
+    sale_price DECIMAL(12,2),
+    ean VARCHAR(50),
+    sku VARCHAR(100),
+    external_id VARCHAR(255),
+    image_url TEXT,
+    stock_status VARCHAR(50) DEFAULT 'instock',
+    stock_quantity INTEGER,
+    affiliate_url TEXT,
+    attributes JSONB DEFAULT '{}'::jsonb, -- size, color...
+    is_active BOOLEAN DEFAULT true,
+    feed_checksum VARCHAR(64),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (product_id, variant_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_variants_product ON product_variants(product_id);
+CREATE INDEX IF NOT EXISTS idx_product_variants_ean ON product_variants(ean);
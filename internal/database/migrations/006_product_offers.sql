@@ -0,0 +1,52 @@
+-- EshopBuilder v3 - Per-feed offers (price comparison)
+-- ================================
+
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+-- PRODUCT OFFERS
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+CREATE TABLE IF NOT EXISTS product_offers (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    feed_id UUID NOT NULL REFERENCES feeds(id) ON DELETE CASCADE,
+    price DECIMAL(12,2) NOT NULL DEFAULT 0,
+    regular_price DECIMAL(12,2),
+    sale_price DECIMAL(12,2),
+    currency VARCHAR(3) DEFAULT 'EUR',
+    stock_status VARCHAR(50) DEFAULT 'instock',
+    stock_quantity INTEGER,
+    delivery_time VARCHAR(100),
+    affiliate_url TEXT,
+    button_text VARCHAR(100) DEFAULT 'Kúpiť',
+    ean VARCHAR(50),
+    sku VARCHAR(100),
+    external_id VARCHAR(255),
+    feed_checksum VARCHAR(64),
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (product_id, feed_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_offers_feed ON product_offers(feed_id);
+CREATE INDEX IF NOT EXISTS idx_product_offers_feed_sku ON product_offers(feed_id, sku);
+CREATE INDEX IF NOT EXISTS idx_product_offers_feed_external ON product_offers(feed_id, external_id);
+
+-- Best offer currently shown on the product
+ALTER TABLE products ADD COLUMN IF NOT EXISTS best_offer_id UUID REFERENCES product_offers(id) ON DELETE SET NULL;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS offer_count INTEGER DEFAULT 0;
+
+-- Existing feed products become the first offer of their feed
+INSERT INTO product_offers (
+    product_id, feed_id, price, regular_price, sale_price, currency, stock_status, stock_quantity,
+    delivery_time, affiliate_url, button_text, ean, sku, external_id, feed_checksum
+)
+SELECT id, feed_id, price, regular_price, sale_price, currency, stock_status, stock_quantity,
+    delivery_time, affiliate_url, button_text, ean, sku, external_id, feed_checksum
+FROM products
+WHERE feed_id IS NOT NULL AND item_group_id IS NULL
+ON CONFLICT (product_id, feed_id) DO NOTHING;
+
+UPDATE products p SET best_offer_id = o.id, offer_count = 1
+FROM product_offers o
+WHERE o.product_id = p.id AND o.feed_id = p.feed_id AND o.is_active = true AND p.best_offer_id IS NULL;
@@ -0,0 +1,7 @@
+-- EshopBuilder v3 - Field locks
+-- ================================
+
+-- Product fields (target field keys) that imports must not overwrite,
+-- e.g. ["title", "description"] after a manual edit
+ALTER TABLE products ADD COLUMN IF NOT EXISTS locked_fields JSONB DEFAULT '[]'::jsonb;
+UPDATE products SET locked_fields = '[]'::jsonb WHERE locked_fields IS NULL;
@@ -0,0 +1,9 @@
+-- EshopBuilder v3 - Change detection
+-- ================================
+
+-- Per-field hashes of the last imported item, used for per-field change counters
+ALTER TABLE product_offers ADD COLUMN IF NOT EXISTS field_checksums JSONB DEFAULT '{}'::jsonb;
+ALTER TABLE product_variants ADD COLUMN IF NOT EXISTS field_checksums JSONB DEFAULT '{}'::jsonb;
+
+-- Field -> number of items whose value changed in the run
+ALTER TABLE import_history ADD COLUMN IF NOT EXISTS field_changes JSONB DEFAULT '{}'::jsonb;
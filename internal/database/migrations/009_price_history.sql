@@ -0,0 +1,25 @@
+-- EshopBuilder v3 - Price history
+-- ================================
+
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+-- PRICE HISTORY (one row per change of price, sale price or stock status)
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+CREATE TABLE IF NOT EXISTS product_price_history (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    price DECIMAL(12,2) NOT NULL,
+    regular_price DECIMAL(12,2),
+    sale_price DECIMAL(12,2),
+    stock_status VARCHAR(50),
+    source VARCHAR(20) NOT NULL DEFAULT 'import',
+    recorded_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_history_product ON product_price_history(product_id, recorded_at DESC);
+CREATE INDEX IF NOT EXISTS idx_price_history_recorded ON product_price_history(recorded_at);
+
+-- Starting point for existing products
+INSERT INTO product_price_history (product_id, price, regular_price, sale_price, stock_status, source, recorded_at)
+SELECT p.id, p.price, p.regular_price, p.sale_price, p.stock_status, 'import', p.updated_at
+FROM products p
+WHERE NOT EXISTS (SELECT 1 FROM product_price_history h WHERE h.product_id = p.id);
@@ -0,0 +1,22 @@
+-- EshopBuilder v3 - Slug redirects
+-- ================================
+
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+-- SLUG REDIRECTS (old product/category URLs -> current slug)
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+CREATE TABLE IF NOT EXISTS slug_redirects (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    entity_type VARCHAR(20) NOT NULL,   -- product, category (type of the old URL)
+    old_slug VARCHAR(255) NOT NULL,
+    target_type VARCHAR(20) NOT NULL,   -- product, category
+    target_slug VARCHAR(255) NOT NULL,
+    status_code INTEGER DEFAULT 301,
+    is_manual BOOLEAN DEFAULT false,
+    hits INTEGER DEFAULT 0,
+    last_hit_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(entity_type, old_slug)
+);
+
+CREATE INDEX IF NOT EXISTS idx_slug_redirects_target ON slug_redirects(target_type, target_slug);
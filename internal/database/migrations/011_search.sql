@@ -0,0 +1,42 @@
+-- EshopBuilder v3 - Full-text search
+-- ================================
+
+CREATE EXTENSION IF NOT EXISTS "unaccent";
+
+-- unaccent() is only STABLE, generated columns and indexes need an IMMUTABLE wrapper
+CREATE OR REPLACE FUNCTION f_unaccent(text) RETURNS text AS $$
+    SELECT public.unaccent('public.unaccent'::regdictionary, $1)
+$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT;
+
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+-- TEXT SEARCH CONFIGURATION
+-- PostgreSQL has no Slovak/Czech stemmer, so words are only unaccented and
+-- lowercased ("čučoriedka" matches "cucoriedka"); prefix queries cover endings
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_ts_config WHERE cfgname = 'sk_unaccent') THEN
+        CREATE TEXT SEARCH CONFIGURATION sk_unaccent (COPY = simple);
+        ALTER TEXT SEARCH CONFIGURATION sk_unaccent
+            ALTER MAPPING FOR hword, hword_part, word WITH unaccent, simple;
+    END IF;
+END
+$$;
+
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+-- SEARCH VECTOR (A = title, B = brand and codes, C = category, D = description)
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+    setweight(to_tsvector('sk_unaccent'::regconfig, COALESCE(title, '')), 'A') ||
+    setweight(to_tsvector('sk_unaccent'::regconfig,
+        COALESCE(brand, '') || ' ' || COALESCE(ean, '') || ' ' || COALESCE(sku, '') || ' ' || COALESCE(mpn, '')), 'B') ||
+    setweight(to_tsvector('sk_unaccent'::regconfig,
+        COALESCE(category_path, '') || ' ' || COALESCE(short_description, '')), 'C') ||
+    setweight(to_tsvector('sk_unaccent'::regconfig, LEFT(COALESCE(description, ''), 5000)), 'D')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING gin (search_vector);
+
+-- Typo tolerant matching on unaccented title and brand
+CREATE INDEX IF NOT EXISTS idx_products_title_unaccent_trgm ON products USING gin (f_unaccent(lower(title)) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_products_brand_trgm ON products USING gin (f_unaccent(lower(brand)) gin_trgm_ops);
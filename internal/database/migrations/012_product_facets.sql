@@ -0,0 +1,11 @@
+-- EshopBuilder v3 - Product facets
+-- ================================
+
+-- attributes @> '{"Farba": "Červená"}' filters
+CREATE INDEX IF NOT EXISTS idx_products_attributes ON products USING gin (attributes jsonb_path_ops);
+
+CREATE INDEX IF NOT EXISTS idx_products_brand ON products(brand) WHERE is_active = true;
+CREATE INDEX IF NOT EXISTS idx_products_stock_status ON products(stock_status) WHERE is_active = true;
+CREATE INDEX IF NOT EXISTS idx_products_delivery_time ON products(delivery_time) WHERE is_active = true;
+CREATE INDEX IF NOT EXISTS idx_products_featured ON products(is_featured) WHERE is_active = true AND is_featured = true;
+CREATE INDEX IF NOT EXISTS idx_products_effective_price ON products((COALESCE(sale_price, price))) WHERE is_active = true;
@@ -0,0 +1,17 @@
+-- EshopBuilder v3 - Category subtree counts
+-- ================================
+
+-- product_count includes products of all descendant categories
+WITH RECURSIVE tree AS (
+    SELECT id AS root_id, id FROM categories
+    UNION
+    SELECT t.root_id, c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+), counts AS (
+    SELECT t.root_id, COUNT(p.id) AS product_count
+    FROM tree t
+    LEFT JOIN products p ON p.category_id = t.id AND p.is_active = true
+    GROUP BY t.root_id
+)
+UPDATE categories c SET product_count = counts.product_count
+FROM counts
+WHERE counts.root_id = c.id AND c.product_count IS DISTINCT FROM counts.product_count;
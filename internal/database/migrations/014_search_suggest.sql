@@ -0,0 +1,19 @@
+-- EshopBuilder v3 - Search autocomplete
+-- ================================
+
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+-- POPULAR QUERIES (completions for the search box)
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+CREATE TABLE IF NOT EXISTS popular_queries (
+    query VARCHAR(255) PRIMARY KEY,      -- unaccented, lowercased
+    display VARCHAR(255) NOT NULL,       -- as typed by the last visitor
+    search_count INTEGER DEFAULT 0,
+    result_count INTEGER DEFAULT 0,
+    last_searched_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_popular_queries_prefix ON popular_queries(query text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_popular_queries_count ON popular_queries(search_count DESC);
+
+-- Substring matching on category names
+CREATE INDEX IF NOT EXISTS idx_categories_name_trgm ON categories USING gin (f_unaccent(lower(name)) gin_trgm_ops);
@@ -0,0 +1,34 @@
+-- EshopBuilder v3 - Search analytics and synonyms
+-- ================================
+
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+-- SEARCH LOG (one row per search, clicked product reported by the storefront)
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+CREATE TABLE IF NOT EXISTS search_log (
+    id UUID PRIMARY KEY,
+    query VARCHAR(255) NOT NULL,         -- normalised (unaccented, lowercased)
+    raw_query VARCHAR(255),
+    result_count INTEGER DEFAULT 0,
+    source VARCHAR(20) DEFAULT 'search', -- search, listing
+    clicked_product_id UUID REFERENCES products(id) ON DELETE SET NULL,
+    clicked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_search_log_created ON search_log(created_at);
+CREATE INDEX IF NOT EXISTS idx_search_log_query ON search_log(query, created_at);
+
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+-- SYNONYMS AND STOPWORDS (query expansion)
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+CREATE TABLE IF NOT EXISTS search_synonyms (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    terms TEXT[] NOT NULL,               -- equivalent terms, e.g. {mobil, telefon, smartfón}
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS search_stopwords (
+    word VARCHAR(100) PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
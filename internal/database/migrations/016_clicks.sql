@@ -0,0 +1,25 @@
+-- EshopBuilder v3 - Affiliate click tracking
+-- ================================
+
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+-- CLICKS (one row per click-out via /go/{slug}, id is sent to the shop as click_id)
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+CREATE TABLE IF NOT EXISTS clicks (
+    id UUID PRIMARY KEY,
+    product_id UUID REFERENCES products(id) ON DELETE SET NULL,
+    offer_id UUID REFERENCES product_offers(id) ON DELETE SET NULL,
+    feed_id UUID REFERENCES feeds(id) ON DELETE SET NULL,
+    target_url TEXT NOT NULL,
+    referrer TEXT,
+    user_agent_hash VARCHAR(64),
+    session_id VARCHAR(64),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_clicks_created ON clicks(created_at);
+CREATE INDEX IF NOT EXISTS idx_clicks_product ON clicks(product_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_clicks_feed ON clicks(feed_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_clicks_session ON clicks(session_id);
+
+ALTER TABLE product_offers ADD COLUMN IF NOT EXISTS click_count INTEGER DEFAULT 0;
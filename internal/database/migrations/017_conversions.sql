@@ -0,0 +1,27 @@
+-- EshopBuilder v3 - Conversion postbacks
+-- ================================
+
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+-- CONVERSIONS (reported by affiliate networks via /postback, matched by click_id)
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+CREATE TABLE IF NOT EXISTS conversions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    click_id UUID NOT NULL REFERENCES clicks(id) ON DELETE CASCADE,
+    order_id VARCHAR(255) NOT NULL DEFAULT '',
+    amount DECIMAL(12,2) DEFAULT 0,      -- order value
+    commission DECIMAL(12,2) DEFAULT 0,  -- our revenue
+    currency VARCHAR(3) DEFAULT 'EUR',
+    status VARCHAR(20) DEFAULT 'pending', -- pending, approved, rejected
+    product_id UUID REFERENCES products(id) ON DELETE SET NULL,
+    feed_id UUID REFERENCES feeds(id) ON DELETE SET NULL,
+    payload JSONB DEFAULT '{}'::jsonb,   -- last postback as received
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (click_id, order_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_conversions_created ON conversions(created_at);
+CREATE INDEX IF NOT EXISTS idx_conversions_status ON conversions(status);
+CREATE INDEX IF NOT EXISTS idx_conversions_product ON conversions(product_id);
+CREATE INDEX IF NOT EXISTS idx_conversions_feed ON conversions(feed_id);
@@ -0,0 +1,26 @@
+-- EshopBuilder v3 - Filtered traffic
+-- ================================
+
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+-- FILTERED TRAFFIC (bot, rate-limited, duplicate and ignored-IP views and clicks,
+-- kept out of the counters used by the dashboard and ranking)
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+CREATE TABLE IF NOT EXISTS filtered_traffic (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    kind VARCHAR(20) NOT NULL,           -- view, click
+    reason VARCHAR(30) NOT NULL,         -- bot, rate_limit, duplicate, ignored_ip
+    product_id UUID REFERENCES products(id) ON DELETE SET NULL,
+    feed_id UUID REFERENCES feeds(id) ON DELETE SET NULL,
+    ip_hash VARCHAR(64),
+    user_agent TEXT,
+    session_id VARCHAR(64),
+    referrer TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_filtered_traffic_created ON filtered_traffic(created_at);
+CREATE INDEX IF NOT EXISTS idx_filtered_traffic_kind ON filtered_traffic(kind, reason, created_at);
+
+-- Identical events are buffered and written as one row with a count
+ALTER TABLE filtered_traffic ADD COLUMN IF NOT EXISTS hits INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,25 @@
+-- EshopBuilder v3 - Daily product stats and popularity
+-- ================================
+
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+-- PRODUCT STATS (human views and clicks per day, flushed in batches from memory)
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+CREATE TABLE IF NOT EXISTS product_stats_daily (
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    day DATE NOT NULL,
+    views INTEGER DEFAULT 0,
+    clicks INTEGER DEFAULT 0,
+    PRIMARY KEY (product_id, day)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_stats_daily_day ON product_stats_daily(day);
+
+-- Recent-window popularity used by search ranking and sort=popular
+ALTER TABLE products ADD COLUMN IF NOT EXISTS popularity_score DOUBLE PRECISION DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_products_popularity ON products(popularity_score DESC);
+
+-- Until daily stats exist, start from the lifetime counters
+UPDATE products SET popularity_score = (view_count + 5 * click_count) / 30.0
+WHERE popularity_score = 0 AND (view_count > 0 OR click_count > 0)
+    AND NOT EXISTS (SELECT 1 FROM product_stats_daily);
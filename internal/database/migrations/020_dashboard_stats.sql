@@ -0,0 +1,47 @@
+-- EshopBuilder v3 - Dashboard statistics
+-- ================================
+
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+-- PRODUCT REMOVALS (deleted or deactivated products, logged by trigger so
+-- handlers, bulk actions and imports are all covered)
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+CREATE TABLE IF NOT EXISTS product_removals (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    product_id UUID NOT NULL,            -- no FK, the product may be gone
+    title VARCHAR(500),
+    feed_id UUID,
+    reason VARCHAR(20) NOT NULL,         -- deleted, deactivated
+    removed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_removals_removed ON product_removals(removed_at);
+
+CREATE OR REPLACE FUNCTION log_product_removal() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        IF OLD.is_active THEN
+            INSERT INTO product_removals (product_id, title, feed_id, reason)
+            VALUES (OLD.id, OLD.title, OLD.feed_id, 'deleted');
+        END IF;
+        RETURN OLD;
+    END IF;
+
+    INSERT INTO product_removals (product_id, title, feed_id, reason)
+    VALUES (NEW.id, NEW.title, NEW.feed_id, 'deactivated');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_products_removed ON products;
+CREATE TRIGGER trg_products_removed AFTER DELETE ON products
+    FOR EACH ROW EXECUTE FUNCTION log_product_removal();
+
+DROP TRIGGER IF EXISTS trg_products_deactivated ON products;
+CREATE TRIGGER trg_products_deactivated AFTER UPDATE OF is_active ON products
+    FOR EACH ROW WHEN (OLD.is_active AND NOT NEW.is_active)
+    EXECUTE FUNCTION log_product_removal();
+
+-- Range queries of the dashboard
+CREATE INDEX IF NOT EXISTS idx_products_created ON products(created_at);
+CREATE INDEX IF NOT EXISTS idx_import_history_started ON import_history(started_at);
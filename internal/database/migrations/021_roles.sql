@@ -0,0 +1,35 @@
+-- EshopBuilder v3 - Roles and permissions
+-- ================================
+
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+-- ROLES (permissions like "products:write", "feeds:*" or "*")
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+CREATE TABLE IF NOT EXISTS roles (
+    name VARCHAR(50) PRIMARY KEY,
+    description TEXT,
+    permissions TEXT[] NOT NULL DEFAULT '{}',
+    is_system BOOLEAN DEFAULT false,     -- built-in, cannot be deleted
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+INSERT INTO roles (name, description, permissions, is_system) VALUES
+    ('admin', 'Full access', '{*}', true),
+    ('editor', 'Manages catalog, feeds and search, read-only settings',
+        '{dashboard:read,reports:read,products:*,categories:*,redirects:*,feeds:*,search:*,settings:read}', true),
+    ('viewer', 'Read-only access',
+        '{dashboard:read,reports:read,products:read,categories:read,redirects:read,feeds:read,search:read,settings:read}', true)
+ON CONFLICT (name) DO NOTHING;
+
+-- Self-registered users used to get "merchant", which had no defined rights
+UPDATE users SET role = 'viewer' WHERE role NOT IN (SELECT name FROM roles);
+ALTER TABLE users ALTER COLUMN role SET DEFAULT 'viewer';
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'users_role_fkey') THEN
+        ALTER TABLE users ADD CONSTRAINT users_role_fkey
+            FOREIGN KEY (role) REFERENCES roles(name) ON UPDATE CASCADE;
+    END IF;
+END $$;
@@ -0,0 +1,21 @@
+-- EshopBuilder v3 - API keys
+-- ================================
+
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+-- API KEYS (X-API-Key header; scopes use the same permissions as roles)
+-- ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(255) NOT NULL,
+    prefix VARCHAR(20) NOT NULL,          -- first characters of the key, shown in the admin
+    key_hash VARCHAR(64) UNIQUE NOT NULL, -- SHA-256 of the whole key
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    allowed_ips TEXT[] NOT NULL DEFAULT '{}', -- IPs / CIDRs, empty = any
+    created_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    last_used_ip VARCHAR(64),
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
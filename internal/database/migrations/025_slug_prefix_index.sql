@@ -0,0 +1,7 @@
+-- EshopBuilder v3 - Slug prefix indexes
+-- ================================
+
+-- slug.Unique looks up "base-%" for every new product / category; the unique
+-- btree indexes can't serve LIKE prefixes under a non-C collation
+CREATE INDEX IF NOT EXISTS idx_products_slug_pattern ON products (slug text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_categories_slug_pattern ON categories (slug text_pattern_ops);
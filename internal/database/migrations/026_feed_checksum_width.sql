@@ -0,0 +1,20 @@
+-- EshopBuilder v3 - Versioned checksum width
+-- ================================
+
+-- Versioned checksums ("v2:" + SHA-256 hex) no longer fit into VARCHAR(64).
+-- Migrations run on every startup, so the columns are only altered (which locks
+-- the table) while they are still too narrow
+DO $$
+DECLARE
+    t TEXT;
+BEGIN
+    FOREACH t IN ARRAY ARRAY['products', 'product_variants', 'product_offers'] LOOP
+        IF EXISTS (
+            SELECT 1 FROM information_schema.columns
+            WHERE table_schema = current_schema() AND table_name = t
+                AND column_name = 'feed_checksum' AND character_maximum_length < 80
+        ) THEN
+            EXECUTE format('ALTER TABLE %I ALTER COLUMN feed_checksum TYPE VARCHAR(80)', t);
+        END IF;
+    END LOOP;
+END $$;
@@ -0,0 +1,5 @@
+-- EshopBuilder v3 - Filtered items per filter
+-- ================================
+
+-- Filter name -> number of items it skipped in the run
+ALTER TABLE import_history ADD COLUMN IF NOT EXISTS filter_counts JSONB DEFAULT '{}'::jsonb;
@@ -0,0 +1,6 @@
+-- EshopBuilder v3 - Filtered clicks
+-- ================================
+
+-- Bot, rate-limited and ignored-IP clicks are stored too, so a conversion on
+-- their click_id is not lost; NULL = human click counted in click stats
+ALTER TABLE clicks ADD COLUMN IF NOT EXISTS filter_reason VARCHAR(30);
@@ -5,6 +5,8 @@ import (
 	"embed"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -43,19 +45,33 @@ func Connect(databaseURL string) (*pgxpool.Pool, error) {
 func RunMigrations(pool *pgxpool.Pool) error {
 	ctx := context.Background()
 
-	// Read migration file
-	content, err := migrations.ReadFile("../migrations/001_schema.sql")
-	if err != nil {
+	// Read migration files
+	entries, err := migrations.ReadDir("migrations")
+	if err != nil || len(entries) == 0 {
 		// Try direct path
 		return runEmbeddedMigrations(pool)
 	}
 
-	_, err = pool.Exec(ctx, string(content))
-	if err != nil {
-		return fmt.Errorf("migration error: %w", err)
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".sql") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		content, err := migrations.ReadFile("migrations/" + name)
+		if err != nil {
+			return fmt.Errorf("migration %s read error: %w", name, err)
+		}
+
+		if _, err := pool.Exec(ctx, string(content)); err != nil {
+			return fmt.Errorf("migration %s error: %w", name, err)
+		}
 	}
 
-	log.Println("✅ Migrations completed")
+	log.Printf("✅ Migrations completed (%d files)", len(names))
 	return nil
 }
 
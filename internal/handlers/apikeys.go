@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"eshopbuilder/internal/auth"
+	"eshopbuilder/internal/middleware"
+	"eshopbuilder/internal/models"
+	"eshopbuilder/internal/rbac"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+// API KEYS
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+// ListAPIKeys - ?status=active|revoked
+func (h *Handler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	query := `
+		SELECT k.id, k.name, k.prefix, k.scopes, k.allowed_ips, k.created_by, u.name,
+			k.expires_at, k.last_used_at, k.last_used_ip, k.revoked_at, k.created_at
+		FROM api_keys k
+		LEFT JOIN users u ON u.id = k.created_by
+		WHERE 1=1`
+	switch r.URL.Query().Get("status") {
+	case "active":
+		query += " AND k.revoked_at IS NULL AND (k.expires_at IS NULL OR k.expires_at > NOW())"
+	case "revoked":
+		query += " AND k.revoked_at IS NOT NULL"
+	}
+	query += " ORDER BY k.created_at DESC"
+
+	rows, err := h.db.Query(r.Context(), query)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		var k models.APIKey
+		rows.Scan(&k.ID, &k.Name, &k.Prefix, &k.Scopes, &k.AllowedIPs, &k.CreatedBy, &k.CreatedByName,
+			&k.ExpiresAt, &k.LastUsedAt, &k.LastUsedIP, &k.RevokedAt, &k.CreatedAt)
+		keys = append(keys, k)
+	}
+
+	h.json(w, http.StatusOK, keys)
+}
+
+// CreateAPIKey - {"name", "scopes", "expires_at", "allowed_ips"}; kľúč sa vráti
+// len v tejto odpovedi, scopes môžu byť len z oprávnení volajúceho
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	// A key is bound to its creator's account, so it cannot be minted by another key
+	userID := middleware.UserID(ctx)
+	if userID == "" {
+		h.error(w, http.StatusForbidden, "API keys can only be created by a signed-in user")
+		return
+	}
+
+	var req struct {
+		Name       string     `json:"name"`
+		Scopes     []string   `json:"scopes"`
+		ExpiresAt  *time.Time `json:"expires_at"`
+		AllowedIPs []string   `json:"allowed_ips"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		h.error(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if len(req.Scopes) == 0 {
+		h.error(w, http.StatusBadRequest, "at least one scope is required")
+		return
+	}
+	if msg := validatePermissions(req.Scopes); msg != "" {
+		h.error(w, http.StatusBadRequest, msg)
+		return
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		h.error(w, http.StatusBadRequest, "expires_at must be in the future")
+		return
+	}
+	allowedIPs := []string{}
+	for _, entry := range req.AllowedIPs {
+		network, err := auth.ParseIPNet(entry)
+		if err != nil {
+			h.error(w, http.StatusBadRequest, "Invalid IP or CIDR "+entry)
+			return
+		}
+		allowedIPs = append(allowedIPs, network.String())
+	}
+
+	granted, err := middleware.Permissions(ctx, h.roles)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Permission check failed")
+		return
+	}
+	for _, scope := range req.Scopes {
+		if !rbac.Allows(granted, scope) {
+			h.error(w, http.StatusForbidden, "You cannot grant scope "+scope)
+			return
+		}
+	}
+
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Key generation failed")
+		return
+	}
+
+	apiKey := models.APIKey{
+		Name:       req.Name,
+		Prefix:     prefix,
+		Scopes:     req.Scopes,
+		AllowedIPs: allowedIPs,
+		CreatedBy:  &userID,
+		ExpiresAt:  req.ExpiresAt,
+		Key:        key,
+	}
+	err = h.db.QueryRow(ctx, `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, allowed_ips, created_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, apiKey.Name, prefix, hash, apiKey.Scopes, allowedIPs, userID, apiKey.ExpiresAt).Scan(&apiKey.ID, &apiKey.CreatedAt)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to create API key")
+		return
+	}
+
+	h.json(w, http.StatusCreated, apiKey)
+}
+
+// RevokeAPIKey - kľúč zostane v zozname so značkou revoked_at
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	result, err := h.db.Exec(r.Context(), `
+		UPDATE api_keys SET revoked_at = NOW() WHERE id::text = $1 AND revoked_at IS NULL
+	`, chi.URLParam(r, "id"))
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to revoke API key")
+		return
+	}
+	if result.RowsAffected() == 0 {
+		h.error(w, http.StatusNotFound, "API key not found")
+		return
+	}
+
+	h.apiKeys.Invalidate()
+	h.json(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"eshopbuilder/internal/redirects"
+	"eshopbuilder/internal/tracking"
+	"eshopbuilder/internal/traffic"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+// CLICK-OUT
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+const (
+	sessionCookie = "eb_session"
+	sessionMaxAge = 30 * 24 * time.Hour
+)
+
+// ClickOut - GET /go/{slug}?offer= zaznamená preklik a presmeruje (302) na affiliate URL
+// s doplnenými UTM / sub-ID parametrami, prípadne obalenú deep-linkom siete feedu
+func (h *Handler) ClickOut(w http.ResponseWriter, r *http.Request) {
+	productSlug := chi.URLParam(r, "slug")
+	ctx := r.Context()
+
+	target, err := tracking.Resolve(ctx, h.db, productSlug, r.URL.Query().Get("offer"))
+	if err == tracking.ErrNotFound {
+		// Old slugs keep working, the click is recorded on the new one
+		if rd, err := redirects.Lookup(ctx, h.db, redirects.TypeProduct, productSlug); err == nil && rd.TargetType == redirects.TypeProduct {
+			location := "/go/" + rd.TargetSlug
+			if r.URL.RawQuery != "" {
+				location += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, location, http.StatusFound)
+			return
+		}
+		h.error(w, http.StatusNotFound, "Product not found")
+		return
+	}
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	event := traffic.Event{
+		Kind:      traffic.KindClick,
+		ProductID: target.ProductID,
+		FeedID:    target.FeedID,
+		IP:        h.traffic.ClientIP(r),
+		UserAgent: r.UserAgent(),
+		SessionID: h.clickSession(w, r),
+		Referrer:  r.Referer(),
+	}
+	clickID := uuid.New().String()
+	verdict := h.traffic.Classify(event, clickID)
+
+	// A repeated click keeps the original click_id so its conversion still matches
+	if verdict.PreviousID != "" {
+		clickID = verdict.PreviousID
+	}
+
+	vars := map[string]string{
+		"click_id":   clickID,
+		"product_id": target.ProductID,
+		"slug":       target.Slug,
+		"feed_id":    stringValue(target.FeedID),
+		"ean":        stringValue(target.EAN),
+		"sku":        stringValue(target.SKU),
+	}
+
+	// Feed parameters override the global ones
+	params := tracking.ParseParams(h.cfg.ClickParams)
+	for key, value := range target.Settings.ClickParams {
+		params[key] = value
+	}
+
+	// UTM parameters belong to the shop URL, the network link wraps it
+	location, err := tracking.AppendParams(target.URL, params, vars)
+	if err == nil {
+		location, err = tracking.Wrap(target.Settings.AffiliateLink, location, vars)
+	}
+	if err != nil {
+		h.error(w, http.StatusBadGateway, "Invalid affiliate URL")
+		return
+	}
+
+	if verdict.Human() {
+		h.stats.Click(target.ProductID)
+	} else {
+		h.recordFiltered(event, verdict)
+	}
+
+	// Every click_id sent to the shop must exist for the conversion postback;
+	// filtered clicks are stored with their reason and left out of click stats.
+	// A duplicate reuses the click_id of the stored original
+	if verdict.PreviousID == "" {
+		click := tracking.Click{
+			ID:            clickID,
+			ProductID:     target.ProductID,
+			OfferID:       target.OfferID,
+			FeedID:        target.FeedID,
+			TargetURL:     location,
+			Referrer:      event.Referrer,
+			UserAgentHash: tracking.HashUserAgent(event.UserAgent),
+			SessionID:     event.SessionID,
+			FilterReason:  verdict.Reason,
+		}
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			if err := tracking.Record(ctx, h.db, click); err != nil {
+				log.Printf("Click %s not recorded: %v", click.ID, err)
+			}
+		}()
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+	http.Redirect(w, r, location, http.StatusFound)
+}
+
+// clickSession vráti ID návštevníka z cookie, prípadne vytvorí nové
+func (h *Handler) clickSession(w http.ResponseWriter, r *http.Request) string {
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		if _, err := uuid.Parse(c.Value); err == nil {
+			return c.Value
+		}
+	}
+
+	id := uuid.New().String()
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(sessionMaxAge.Seconds()),
+		HttpOnly: true,
+		Secure:   r.TLS != nil,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return id
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
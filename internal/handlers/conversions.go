@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"eshopbuilder/internal/models"
+	"eshopbuilder/internal/tracking"
+
+	"github.com/google/uuid"
+)
+
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+// CONVERSION POSTBACK
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+const postbackMaxBytes = 64 * 1024
+
+// Postback - GET/POST /postback od affiliate siete: click_id, order_id, amount, commission,
+// currency, status. Zabezpečené tokenom (?token=, X-Postback-Token) alebo HMAC podpisom
+// (X-Signature, ?signature=) tela POST požiadavky, resp. zoradených query parametrov.
+func (h *Handler) Postback(w http.ResponseWriter, r *http.Request) {
+	if h.cfg.PostbackToken == "" && h.cfg.PostbackSecret == "" {
+		h.error(w, http.StatusServiceUnavailable, "Postback is not configured")
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, postbackMaxBytes))
+	if err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	params, err := postbackParams(r, body)
+	if err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !h.postbackAuthorized(r, body, params) {
+		h.error(w, http.StatusUnauthorized, "Invalid postback token or signature")
+		return
+	}
+	delete(params, "token")
+	delete(params, "signature")
+
+	// Networks name the sub-ID we sent at click-out differently
+	c := tracking.Conversion{
+		ClickID:  firstParam(params, "click_id", "clickref", "data1", "sid", "subid"),
+		OrderID:  firstParam(params, "order_id", "orderid", "transaction_id"),
+		Currency: strings.ToUpper(firstParam(params, "currency")),
+		Payload:  params,
+	}
+	if _, err := uuid.Parse(c.ClickID); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid click_id")
+		return
+	}
+	if len(c.OrderID) > 255 || len(c.Currency) > 3 {
+		h.error(w, http.StatusBadRequest, "Invalid order_id or currency")
+		return
+	}
+	if c.Amount, err = parseAmount(firstParam(params, "amount", "order_value")); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid amount")
+		return
+	}
+	if c.Commission, err = parseAmount(firstParam(params, "commission", "payout")); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid commission")
+		return
+	}
+	status, ok := tracking.NormalizeStatus(firstParam(params, "status"))
+	if !ok {
+		h.error(w, http.StatusBadRequest, "status must be pending, approved or rejected")
+		return
+	}
+	c.Status = status
+
+	created, err := tracking.SaveConversion(r.Context(), h.db, c)
+	if err == tracking.ErrUnknownClick {
+		h.error(w, http.StatusNotFound, "Unknown click_id")
+		return
+	}
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to save conversion")
+		return
+	}
+
+	if created {
+		h.json(w, http.StatusCreated, map[string]string{"status": "created"})
+		return
+	}
+	h.json(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+// postbackParams spojí query parametre s telom (JSON alebo formulár)
+func postbackParams(r *http.Request, body []byte) (map[string]string, error) {
+	params := make(map[string]string)
+	for key := range r.URL.Query() {
+		params[key] = r.URL.Query().Get(key)
+	}
+	if len(body) == 0 {
+		return params, nil
+	}
+
+	contentType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if contentType == "application/json" {
+		var data map[string]interface{}
+		if err := json.Unmarshal(body, &data); err != nil {
+			return nil, err
+		}
+		for key, value := range data {
+			if value != nil {
+				params[key] = fmt.Sprint(value)
+			}
+		}
+		return params, nil
+	}
+
+	values, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, err
+	}
+	for key := range values {
+		params[key] = values.Get(key)
+	}
+	return params, nil
+}
+
+// postbackAuthorized overí token alebo HMAC podpis
+func (h *Handler) postbackAuthorized(r *http.Request, body []byte, params map[string]string) bool {
+	if h.cfg.PostbackToken != "" {
+		token := r.Header.Get("X-Postback-Token")
+		if token == "" {
+			token = params["token"]
+		}
+		if subtle.ConstantTimeCompare([]byte(token), []byte(h.cfg.PostbackToken)) == 1 {
+			return true
+		}
+	}
+
+	if h.cfg.PostbackSecret != "" {
+		signature := r.Header.Get("X-Signature")
+		if signature == "" {
+			signature = params["signature"]
+		}
+
+		// GET requests sign the sorted query without the signature itself
+		payload := body
+		if len(body) == 0 {
+			query := r.URL.Query()
+			query.Del("signature")
+			payload = []byte(query.Encode())
+		}
+		return tracking.VerifySignature(h.cfg.PostbackSecret, payload, signature)
+	}
+
+	return false
+}
+
+func firstParam(params map[string]string, names ...string) string {
+	for _, name := range names {
+		if value := strings.TrimSpace(params[name]); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseAmount - prázdna suma je 0, akceptuje aj desatinnú čiarku
+func parseAmount(value string) (float64, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(strings.Replace(value, ",", ".", 1), 64)
+}
+
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+// CONVERSION REPORTS
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+// conversionGroups - kľúč a názov skupiny reportu
+var conversionGroups = map[string][2]string{
+	"product":  {"COALESCE(c.product_id::text, '')", "COALESCE(p.title, '')"},
+	"category": {"COALESCE(p.category_id::text, '')", "COALESCE(cat.name, '')"},
+	"brand":    {"COALESCE(p.brand, '')", "COALESCE(p.brand, '')"},
+	"feed":     {"COALESCE(c.feed_id::text, '')", "COALESCE(f.name, '')"},
+}
+
+// GetConversionReport - ?group_by=product|category|brand|feed, ?days=30, ?limit=50;
+// konverzie sa priraďujú k dátumu prekliku
+func (h *Handler) GetConversionReport(w http.ResponseWriter, r *http.Request) {
+	groupBy := r.URL.Query().Get("group_by")
+	if groupBy == "" {
+		groupBy = "product"
+	}
+	group, ok := conversionGroups[groupBy]
+	if !ok {
+		h.error(w, http.StatusBadRequest, "group_by must be product, category, brand or feed")
+		return
+	}
+	days, limit := analyticsRange(r)
+	ctx := r.Context()
+
+	rows, err := h.conversionReport(ctx, group[0], group[1], days, limit)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	totals, err := h.conversionReport(ctx, "''", "''", days, 1)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	var total models.ConversionReportRow
+	if len(totals) > 0 {
+		total = totals[0]
+	}
+
+	h.json(w, http.StatusOK, map[string]interface{}{
+		"group_by": groupBy,
+		"days":     days,
+		"rows":     rows,
+		"totals":   total,
+	})
+}
+
+func (h *Handler) conversionReport(ctx context.Context, key, name string, days, limit int) ([]models.ConversionReportRow, error) {
+	rows, err := h.db.Query(ctx, `
+		SELECT `+key+`, `+name+`,
+			COUNT(DISTINCT c.id) FILTER (WHERE c.filter_reason IS NULL),
+			COUNT(v.id) FILTER (WHERE v.status <> 'rejected'),
+			COALESCE(SUM(v.amount) FILTER (WHERE v.status <> 'rejected'), 0),
+			COALESCE(SUM(v.commission) FILTER (WHERE v.status <> 'rejected'), 0),
+			COALESCE(SUM(v.commission) FILTER (WHERE v.status = 'approved'), 0)
+		FROM clicks c
+		LEFT JOIN conversions v ON v.click_id = c.id
+		LEFT JOIN products p ON p.id = c.product_id
+		LEFT JOIN categories cat ON cat.id = p.category_id
+		LEFT JOIN feeds f ON f.id = c.feed_id
+		WHERE c.created_at >= NOW() - make_interval(days => $1)
+		GROUP BY 1, 2
+		ORDER BY 6 DESC, 3 DESC
+		LIMIT $2
+	`, days, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.ConversionReportRow{}
+	for rows.Next() {
+		var row models.ConversionReportRow
+		if err := rows.Scan(&row.Key, &row.Name, &row.Clicks, &row.Conversions,
+			&row.OrderValue, &row.Revenue, &row.ApprovedRevenue); err != nil {
+			return nil, err
+		}
+		if row.Clicks > 0 {
+			row.ConversionRate = float64(row.Conversions) / float64(row.Clicks)
+			row.EPC = row.Revenue / float64(row.Clicks)
+		}
+		list = append(list, row)
+	}
+	return list, rows.Err()
+}
+
+// ListConversions - posledné konverzie, ?status=, stránkovanie
+func (h *Handler) ListConversions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage := 50
+	offset := (page - 1) * perPage
+	status := r.URL.Query().Get("status")
+
+	rows, err := h.db.Query(ctx, `
+		SELECT v.id, v.click_id, v.order_id, v.amount, v.commission, v.currency, v.status,
+			v.product_id, p.title, v.feed_id, v.created_at, v.updated_at
+		FROM conversions v
+		LEFT JOIN products p ON p.id = v.product_id
+		WHERE ($1 = '' OR v.status = $1)
+		ORDER BY v.created_at DESC
+		LIMIT $2 OFFSET $3
+	`, status, perPage, offset)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	defer rows.Close()
+
+	list := []models.Conversion{}
+	for rows.Next() {
+		var c models.Conversion
+		rows.Scan(&c.ID, &c.ClickID, &c.OrderID, &c.Amount, &c.Commission, &c.Currency, &c.Status,
+			&c.ProductID, &c.ProductTitle, &c.FeedID, &c.CreatedAt, &c.UpdatedAt)
+		list = append(list, c)
+	}
+
+	var total int
+	h.db.QueryRow(ctx, "SELECT COUNT(*) FROM conversions WHERE ($1 = '' OR status = $1)", status).Scan(&total)
+
+	h.json(w, http.StatusOK, map[string]interface{}{
+		"conversions": list,
+		"total":       total,
+		"page":        page,
+		"per_page":    perPage,
+	})
+}
@@ -0,0 +1,327 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"eshopbuilder/internal/models"
+)
+
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+// DASHBOARD STATISTICS
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+// maxStatsPoints - ochrana pred príliš jemným rozlíšením dlhého obdobia
+const maxStatsPoints = 400
+
+// feedStaleAfter - feed bez úspešného importu dlhšie je "stale"
+const feedStaleAfter = 48 * time.Hour
+
+// statsRange - ?from=, ?to= (YYYY-MM-DD alebo RFC3339, vrátane), predvolene posledných 30 dní;
+// to sa vráti ako exkluzívny koniec
+func statsRange(r *http.Request) (from, to time.Time, msg string) {
+	today := time.Now().Truncate(24 * time.Hour)
+	to = today.AddDate(0, 0, 1)
+	from = today.AddDate(0, 0, -29)
+
+	if value := r.URL.Query().Get("to"); value != "" {
+		t, err := parseSince(value)
+		if err != nil {
+			return from, to, "Invalid to"
+		}
+		to = t.Truncate(24*time.Hour).AddDate(0, 0, 1)
+	}
+	if value := r.URL.Query().Get("from"); value != "" {
+		t, err := parseSince(value)
+		if err != nil {
+			return from, to, "Invalid from"
+		}
+		from = t.Truncate(24 * time.Hour)
+	} else if r.URL.Query().Get("to") != "" {
+		from = to.AddDate(0, 0, -30)
+	}
+
+	if !from.Before(to) {
+		return from, to, "from must be before to"
+	}
+	return from, to, ""
+}
+
+// GetStatsTimeseries - súčty a časový rad za obdobie (?from=, ?to=, ?granularity=day|week|month)
+func (h *Handler) GetStatsTimeseries(w http.ResponseWriter, r *http.Request) {
+	from, to, msg := statsRange(r)
+	if msg != "" {
+		h.error(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	granularity := r.URL.Query().Get("granularity")
+	if granularity == "" {
+		granularity = "day"
+	}
+	var step time.Duration
+	switch granularity {
+	case "day":
+		step = 24 * time.Hour
+	case "week":
+		step = 7 * 24 * time.Hour
+	case "month":
+		step = 30 * 24 * time.Hour
+	default:
+		h.error(w, http.StatusBadRequest, "granularity must be day, week or month")
+		return
+	}
+	if to.Sub(from)/step > maxStatsPoints {
+		h.error(w, http.StatusBadRequest, "Range too long for granularity "+granularity)
+		return
+	}
+
+	points, err := h.statsSeries(r.Context(), from, to, granularity)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	var totals models.StatsPoint
+	for _, p := range points {
+		totals.ProductsAdded += p.ProductsAdded
+		totals.ProductsRemoved += p.ProductsRemoved
+		totals.ImportsRun += p.ImportsRun
+		totals.ImportsFailed += p.ImportsFailed
+		totals.Views += p.Views
+		totals.Clicks += p.Clicks
+		totals.Conversions += p.Conversions
+		totals.Revenue += p.Revenue
+	}
+
+	h.json(w, http.StatusOK, map[string]interface{}{
+		"from":        from.Format("2006-01-02"),
+		"to":          to.AddDate(0, 0, -1).Format("2006-01-02"),
+		"granularity": granularity,
+		"totals": map[string]interface{}{
+			"products_added":   totals.ProductsAdded,
+			"products_removed": totals.ProductsRemoved,
+			"imports_run":      totals.ImportsRun,
+			"imports_failed":   totals.ImportsFailed,
+			"views":            totals.Views,
+			"clicks":           totals.Clicks,
+			"conversions":      totals.Conversions,
+			"revenue":          totals.Revenue,
+		},
+		"points": points,
+	})
+}
+
+// statsSeries - každá metrika sa agreguje jedným GROUP BY, prázdne intervaly dopĺňa generate_series
+func (h *Handler) statsSeries(ctx context.Context, from, to time.Time, granularity string) ([]models.StatsPoint, error) {
+	rows, err := h.db.Query(ctx, `
+		WITH buckets AS (
+			SELECT generate_series(date_trunc($3, $1::timestamptz), $2::timestamptz - interval '1 second',
+				('1 ' || $3)::interval) AS period
+		),
+		added AS (
+			SELECT date_trunc($3, created_at) AS period, COUNT(*) AS n
+			FROM products WHERE created_at >= $1 AND created_at < $2
+			GROUP BY 1
+		),
+		removed AS (
+			SELECT date_trunc($3, removed_at) AS period, COUNT(*) AS n
+			FROM product_removals WHERE removed_at >= $1 AND removed_at < $2
+			GROUP BY 1
+		),
+		imports AS (
+			SELECT date_trunc($3, started_at) AS period, COUNT(*) AS n,
+				COUNT(*) FILTER (WHERE status = 'failed') AS failed
+			FROM import_history WHERE started_at >= $1 AND started_at < $2
+			GROUP BY 1
+		),
+		traffic AS (
+			SELECT date_trunc($3, day::timestamptz) AS period, SUM(views) AS views, SUM(clicks) AS clicks
+			FROM product_stats_daily WHERE day >= $1::date AND day < $2::date
+			GROUP BY 1
+		),
+		conv AS (
+			SELECT date_trunc($3, created_at) AS period, COUNT(*) AS n, SUM(commission) AS revenue
+			FROM conversions WHERE created_at >= $1 AND created_at < $2 AND status <> 'rejected'
+			GROUP BY 1
+		)
+		SELECT b.period, COALESCE(a.n, 0), COALESCE(rm.n, 0), COALESCE(i.n, 0), COALESCE(i.failed, 0),
+			COALESCE(t.views, 0), COALESCE(t.clicks, 0), COALESCE(c.n, 0), COALESCE(c.revenue, 0)
+		FROM buckets b
+		LEFT JOIN added a ON a.period = b.period
+		LEFT JOIN removed rm ON rm.period = b.period
+		LEFT JOIN imports i ON i.period = b.period
+		LEFT JOIN traffic t ON t.period = b.period
+		LEFT JOIN conv c ON c.period = b.period
+		ORDER BY b.period
+	`, from, to, granularity)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []models.StatsPoint{}
+	for rows.Next() {
+		var p models.StatsPoint
+		if err := rows.Scan(&p.Period, &p.ProductsAdded, &p.ProductsRemoved, &p.ImportsRun, &p.ImportsFailed,
+			&p.Views, &p.Clicks, &p.Conversions, &p.Revenue); err != nil {
+			return nil, err
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// topGroups - kľúč, názov a podmienka rebríčka
+var topGroups = map[string][3]string{
+	"products":   {"p.id::text", "p.title", "true"},
+	"categories": {"cat.id::text", "cat.name", "cat.id IS NOT NULL"},
+	"brands":     {"p.brand", "p.brand", "COALESCE(p.brand, '') <> ''"},
+}
+
+// GetStatsTop - najlepšie produkty, kategórie a značky za obdobie (?from=, ?to=, ?limit=10)
+// podľa provízií, preklikov a zobrazení
+func (h *Handler) GetStatsTop(w http.ResponseWriter, r *http.Request) {
+	from, to, msg := statsRange(r)
+	if msg != "" {
+		h.error(w, http.StatusBadRequest, msg)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	response := map[string]interface{}{
+		"from": from.Format("2006-01-02"),
+		"to":   to.AddDate(0, 0, -1).Format("2006-01-02"),
+	}
+	for name, group := range topGroups {
+		list, err := h.statsTop(r.Context(), group, from, to, limit)
+		if err != nil {
+			h.error(w, http.StatusInternalServerError, "Database error")
+			return
+		}
+		response[name] = list
+	}
+
+	h.json(w, http.StatusOK, response)
+}
+
+func (h *Handler) statsTop(ctx context.Context, group [3]string, from, to time.Time, limit int) ([]models.TopEntry, error) {
+	rows, err := h.db.Query(ctx, `
+		WITH traffic AS (
+			SELECT product_id, SUM(views) AS views, SUM(clicks) AS clicks
+			FROM product_stats_daily WHERE day >= $1::date AND day < $2::date
+			GROUP BY product_id
+		),
+		conv AS (
+			SELECT product_id, COUNT(*) AS n, SUM(commission) AS revenue
+			FROM conversions
+			WHERE created_at >= $1 AND created_at < $2 AND status <> 'rejected' AND product_id IS NOT NULL
+			GROUP BY product_id
+		),
+		per_product AS (
+			SELECT COALESCE(t.product_id, c.product_id) AS product_id,
+				COALESCE(t.views, 0) AS views, COALESCE(t.clicks, 0) AS clicks,
+				COALESCE(c.n, 0) AS conversions, COALESCE(c.revenue, 0) AS revenue
+			FROM traffic t
+			FULL JOIN conv c ON c.product_id = t.product_id
+		)
+		SELECT `+group[0]+`, `+group[1]+`, SUM(pp.views), SUM(pp.clicks), SUM(pp.conversions), SUM(pp.revenue)
+		FROM per_product pp
+		JOIN products p ON p.id = pp.product_id
+		LEFT JOIN categories cat ON cat.id = p.category_id
+		WHERE `+group[2]+`
+		GROUP BY 1, 2
+		ORDER BY 6 DESC, 4 DESC, 3 DESC
+		LIMIT $3
+	`, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.TopEntry{}
+	for rows.Next() {
+		var e models.TopEntry
+		if err := rows.Scan(&e.ID, &e.Name, &e.Views, &e.Clicks, &e.Conversions, &e.Revenue); err != nil {
+			return nil, err
+		}
+		list = append(list, e)
+	}
+	return list, rows.Err()
+}
+
+// GetFeedHealth - stav importov každého feedu (?from=, ?to= pre počty behov)
+func (h *Handler) GetFeedHealth(w http.ResponseWriter, r *http.Request) {
+	from, to, msg := statsRange(r)
+	if msg != "" {
+		h.error(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	rows, err := h.db.Query(r.Context(), `
+		SELECT f.id, f.name, f.active, f.last_run, f.last_error,
+			last.status, ok.finished_at,
+			COALESCE(runs.n, 0), COALESCE(runs.failed, 0), COALESCE(runs.avg_duration, 0),
+			(SELECT COUNT(*) FROM product_offers o WHERE o.feed_id = f.id AND o.is_active = true),
+			(SELECT COUNT(*) FROM import_history ih
+				WHERE ih.feed_id = f.id AND ih.status = 'failed'
+					AND ih.started_at > COALESCE(ok.finished_at, '-infinity'::timestamptz))
+		FROM feeds f
+		LEFT JOIN LATERAL (
+			SELECT status FROM import_history
+			WHERE feed_id = f.id AND status <> 'running'
+			ORDER BY started_at DESC LIMIT 1
+		) last ON true
+		LEFT JOIN LATERAL (
+			SELECT MAX(finished_at) AS finished_at FROM import_history
+			WHERE feed_id = f.id AND status = 'completed'
+		) ok ON true
+		LEFT JOIN LATERAL (
+			SELECT COUNT(*) AS n, COUNT(*) FILTER (WHERE status = 'failed') AS failed,
+				AVG(duration)::float8 AS avg_duration
+			FROM import_history
+			WHERE feed_id = f.id AND started_at >= $1 AND started_at < $2
+		) runs ON true
+		ORDER BY f.name
+	`, from, to)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	defer rows.Close()
+
+	list := []models.FeedHealth{}
+	for rows.Next() {
+		var f models.FeedHealth
+		rows.Scan(&f.FeedID, &f.Name, &f.Active, &f.LastRun, &f.LastError,
+			&f.LastStatus, &f.LastSuccessAt, &f.Runs, &f.Failed, &f.AvgDuration,
+			&f.ActiveOffers, &f.ConsecutiveFails)
+
+		if f.Runs > 0 {
+			f.SuccessRate = float64(f.Runs-f.Failed) / float64(f.Runs)
+		}
+		f.Health = feedHealth(f)
+		list = append(list, f)
+	}
+
+	h.json(w, http.StatusOK, list)
+}
+
+func feedHealth(f models.FeedHealth) string {
+	switch {
+	case !f.Active:
+		return "disabled"
+	case f.LastStatus == nil:
+		return "never"
+	case *f.LastStatus == string(models.ImportStatusFailed):
+		return "failing"
+	case f.LastSuccessAt == nil || time.Since(*f.LastSuccessAt) > feedStaleAfter:
+		return "stale"
+	}
+	return "ok"
+}
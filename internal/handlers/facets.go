@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"eshopbuilder/internal/models"
+	"eshopbuilder/internal/sqlbuilder"
+)
+
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+// PRODUCT FACETS
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+const (
+	maxFacetValues     = 50
+	maxAttributeFacets = 20
+	maxAttributeValues = 30
+)
+
+// facetSelection - Hodnoty filtrov vybrané v požiadavke
+type facetSelection struct {
+	Brands       []string
+	StockStatus  []string
+	DeliveryTime []string
+	Attributes   map[string][]string
+}
+
+// parseFacetFilters pridá filtre faziet z query parametrov:
+// brand, stock_status, delivery_time (opakované alebo oddelené čiarkou), min_price,
+// max_price, featured=true a attr[Kľúč]=hodnota (viac hodnôt jedného kľúča = OR)
+func parseFacetFilters(filters *sqlbuilder.Filters, params url.Values) facetSelection {
+	sel := facetSelection{
+		Brands:       listParam(params, "brand"),
+		StockStatus:  listParam(params, "stock_status"),
+		DeliveryTime: listParam(params, "delivery_time"),
+		Attributes:   make(map[string][]string),
+	}
+
+	if len(sel.Brands) > 0 {
+		filters.Add("brand", "products.brand = ANY($?)", sel.Brands)
+	}
+	if len(sel.StockStatus) > 0 {
+		filters.Add("stock_status", "products.stock_status = ANY($?)", sel.StockStatus)
+	}
+	if len(sel.DeliveryTime) > 0 {
+		filters.Add("delivery_time", "products.delivery_time = ANY($?)", sel.DeliveryTime)
+	}
+
+	if v, err := strconv.ParseFloat(params.Get("min_price"), 64); err == nil {
+		filters.Add("price", "COALESCE(products.sale_price, products.price) >= $?", v)
+	}
+	if v, err := strconv.ParseFloat(params.Get("max_price"), 64); err == nil {
+		filters.Add("price", "COALESCE(products.sale_price, products.price) <= $?", v)
+	}
+	if params.Get("featured") == "true" {
+		filters.Add("featured", "products.is_featured = true")
+	}
+
+	keys := []string{}
+	for param, values := range params {
+		key, ok := strings.CutPrefix(param, "attr[")
+		if !ok || !strings.HasSuffix(key, "]") {
+			continue
+		}
+		key = strings.TrimSuffix(key, "]")
+		for _, value := range values {
+			if value != "" {
+				sel.Attributes[key] = append(sel.Attributes[key], value)
+			}
+		}
+		if len(sel.Attributes[key]) > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		values := sel.Attributes[key]
+		conds := make([]string, len(values))
+		args := make([]interface{}, len(values))
+		for i, value := range values {
+			doc, _ := json.Marshal(map[string]string{key: value})
+			conds[i] = "products.attributes @> $?::jsonb"
+			args[i] = string(doc)
+		}
+		filters.Add("attr:"+key, "("+strings.Join(conds, " OR ")+")", args...)
+	}
+
+	return sel
+}
+
+// productFacets spočíta fazety; každá fazeta ignoruje vlastný filter,
+// aby sa dali vybrať ďalšie hodnoty (disjunktívne počty)
+func (h *Handler) productFacets(ctx context.Context, filters *sqlbuilder.Filters, joins func(*sqlbuilder.Builder) string, sel facetSelection) *models.Facets {
+	facets := &models.Facets{
+		Brands:       h.facetValues(ctx, filters, joins, "brand", "products.brand", sel.Brands),
+		StockStatus:  h.facetValues(ctx, filters, joins, "stock_status", "products.stock_status", sel.StockStatus),
+		DeliveryTime: h.facetValues(ctx, filters, joins, "delivery_time", "products.delivery_time", sel.DeliveryTime),
+		Attributes:   []models.AttributeFacet{},
+	}
+
+	b := &sqlbuilder.Builder{}
+	from := "FROM products " + joins(b)
+	h.db.QueryRow(ctx, `
+		SELECT COUNT(*) `+from+` WHERE products.is_featured = true AND `+filters.Render(b, "featured"),
+		b.Args()...).Scan(&facets.Featured)
+
+	b = &sqlbuilder.Builder{}
+	from = "FROM products " + joins(b)
+	h.db.QueryRow(ctx, `
+		SELECT COALESCE(MIN(COALESCE(products.sale_price, products.price)), 0),
+			COALESCE(MAX(COALESCE(products.sale_price, products.price)), 0)
+		`+from+` WHERE `+filters.Render(b, "price"),
+		b.Args()...).Scan(&facets.Price.Min, &facets.Price.Max)
+
+	// Unselected attributes are counted with all filters applied,
+	// each selected attribute without its own filter
+	exclude := []string{}
+	for key := range sel.Attributes {
+		exclude = append(exclude, key)
+	}
+	byKey := h.attributeValues(ctx, filters, joins, nil, exclude)
+	for key := range sel.Attributes {
+		for k, values := range h.attributeValues(ctx, filters, joins, &key, nil) {
+			byKey[k] = values
+		}
+	}
+
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	// Selected attributes first, then by number of products
+	total := func(values []models.FacetValue) int {
+		sum := 0
+		for _, v := range values {
+			sum += v.Count
+		}
+		return sum
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		_, si := sel.Attributes[keys[i]]
+		_, sj := sel.Attributes[keys[j]]
+		if si != sj {
+			return si
+		}
+		ti, tj := total(byKey[keys[i]]), total(byKey[keys[j]])
+		if ti != tj {
+			return ti > tj
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > maxAttributeFacets {
+		keys = keys[:maxAttributeFacets]
+	}
+
+	for _, key := range keys {
+		values := byKey[key]
+		for i := range values {
+			values[i].Selected = containsValue(sel.Attributes[key], values[i].Value)
+		}
+		facets.Attributes = append(facets.Attributes, models.AttributeFacet{Key: key, Values: values})
+	}
+
+	return facets
+}
+
+func (h *Handler) facetValues(ctx context.Context, filters *sqlbuilder.Filters, joins func(*sqlbuilder.Builder) string, name, column string, selected []string) []models.FacetValue {
+	b := &sqlbuilder.Builder{}
+	from := "FROM products " + joins(b)
+	where := filters.Render(b, name)
+
+	values := []models.FacetValue{}
+	rows, err := h.db.Query(ctx, `
+		SELECT `+column+`, COUNT(*) `+from+`
+		WHERE `+column+` IS NOT NULL AND `+column+` <> '' AND `+where+`
+		GROUP BY 1
+		ORDER BY 2 DESC, 1
+		LIMIT `+strconv.Itoa(maxFacetValues),
+		b.Args()...)
+	if err != nil {
+		return values
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v models.FacetValue
+		rows.Scan(&v.Value, &v.Count)
+		v.Selected = containsValue(selected, v.Value)
+		values = append(values, v)
+	}
+	return values
+}
+
+// attributeValues spočíta hodnoty atribútov; only = len jeden kľúč bez jeho filtra,
+// inak všetky kľúče okrem vynechaných
+func (h *Handler) attributeValues(ctx context.Context, filters *sqlbuilder.Filters, joins func(*sqlbuilder.Builder) string, only *string, exclude []string) map[string][]models.FacetValue {
+	b := &sqlbuilder.Builder{}
+	from := "FROM products " + joins(b)
+
+	var where string
+	if only != nil {
+		where = filters.Render(b, "attr:"+*only) + " AND a.key = " + b.Arg(*only)
+	} else {
+		where = filters.Render(b)
+		if len(exclude) > 0 {
+			where += " AND NOT (a.key = ANY(" + b.Arg(exclude) + "))"
+		}
+	}
+
+	result := make(map[string][]models.FacetValue)
+	rows, err := h.db.Query(ctx, `
+		SELECT a.key, a.value, COUNT(*) `+from+`
+		CROSS JOIN LATERAL jsonb_each_text(
+			CASE WHEN jsonb_typeof(products.attributes) = 'object' THEN products.attributes ELSE '{}'::jsonb END
+		) a
+		WHERE a.value <> '' AND `+where+`
+		GROUP BY a.key, a.value
+		ORDER BY 3 DESC
+		LIMIT 1000
+	`, b.Args()...)
+	if err != nil {
+		return result
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var v models.FacetValue
+		rows.Scan(&key, &v.Value, &v.Count)
+		if len(result[key]) < maxAttributeValues {
+			result[key] = append(result[key], v)
+		}
+	}
+	return result
+}
+
+// listParam vráti hodnoty opakovaného parametra, hodnoty môžu byť oddelené čiarkou
+func listParam(params url.Values, name string) []string {
+	values := []string{}
+	for _, raw := range params[name] {
+		for _, v := range strings.Split(raw, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+func containsValue(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
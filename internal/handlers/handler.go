@@ -3,32 +3,67 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"strconv"
 	"sync"
 	"time"
 
+	"eshopbuilder/internal/auth"
 	"eshopbuilder/internal/config"
 	"eshopbuilder/internal/importer"
+	"eshopbuilder/internal/middleware"
 	"eshopbuilder/internal/models"
+	"eshopbuilder/internal/pricehistory"
+	"eshopbuilder/internal/rbac"
+	"eshopbuilder/internal/redirects"
+	"eshopbuilder/internal/search"
+	"eshopbuilder/internal/slug"
+	"eshopbuilder/internal/sqlbuilder"
+	"eshopbuilder/internal/stats"
+	"eshopbuilder/internal/tracking"
+	"eshopbuilder/internal/traffic"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type Handler struct {
 	db            *pgxpool.Pool
 	cfg           *config.Config
+	searcher      *search.Searcher
+	traffic       *traffic.Classifier
+	filtered      *traffic.Recorder
+	stats         *stats.Counter
+	roles         *rbac.Store
+	sessions      *auth.Sessions
+	apiKeys       *auth.APIKeys
 	importEngines sync.Map // feedID -> *importer.ImportEngine
 }
 
-func New(db *pgxpool.Pool, cfg *config.Config) *Handler {
+func New(db *pgxpool.Pool, cfg *config.Config, counter *stats.Counter, filtered *traffic.Recorder,
+	roles *rbac.Store, sessions *auth.Sessions, apiKeys *auth.APIKeys) *Handler {
 	return &Handler{
-		db:  db,
-		cfg: cfg,
+		db:       db,
+		cfg:      cfg,
+		searcher: search.New(db),
+		stats:    counter,
+		filtered: filtered,
+		roles:    roles,
+		sessions: sessions,
+		apiKeys:  apiKeys,
+		traffic: traffic.New(traffic.Config{
+			BotAgents:       cfg.TrafficBotAgents,
+			IgnoredIPs:      cfg.TrafficIgnoredIPs,
+			MaxPerMinute:    cfg.TrafficMaxPerMinute,
+			DuplicateWindow: time.Duration(cfg.TrafficDuplicateWindow) * time.Second,
+			TrustProxy:      cfg.TrustProxy,
+			TrustRealIP:     cfg.TrustRealIP,
+		}),
 	}
 }
 
@@ -53,9 +88,11 @@ type LoginRequest struct {
 }
 
 type AuthResponse struct {
-	Token     string       `json:"token"`
-	ExpiresAt int64        `json:"expires_at"`
-	User      *models.User `json:"user"`
+	Token        string       `json:"token"`
+	ExpiresAt    int64        `json:"expires_at"`
+	RefreshToken string       `json:"refresh_token"` // single use, send to /auth/refresh
+	User         *models.User `json:"user"`
+	Permissions  []string     `json:"permissions"`
 }
 
 func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
@@ -70,31 +107,43 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	var user models.User
 	err := h.db.QueryRow(ctx, `
 		SELECT id, email, password_hash, name, role, is_active
-		FROM users WHERE email = $1
-	`, req.Email).Scan(&user.ID, &user.Email, &user.PasswordHash, &user.Name, &user.Role, &user.IsActive)
+		FROM users WHERE lower(email) = $1
+	`, normalizeEmail(req.Email)).Scan(&user.ID, &user.Email, &user.PasswordHash, &user.Name, &user.Role, &user.IsActive)
 
 	if err != nil {
 		h.error(w, http.StatusUnauthorized, "Invalid credentials")
 		return
 	}
 
-	if !user.IsActive {
-		h.error(w, http.StatusUnauthorized, "Account is disabled")
-		return
-	}
-
-	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+	// Disabled accounts get the same answer, so emails of deactivated users don't leak
+	if !auth.CheckPassword(user.PasswordHash, req.Password) || !user.IsActive {
 		h.error(w, http.StatusUnauthorized, "Invalid credentials")
 		return
 	}
 
 	// Update last login
-	h.db.Exec(ctx, "UPDATE users SET last_login = NOW() WHERE id = $1", user.ID)
+	h.db.QueryRow(ctx, "UPDATE users SET last_login = NOW() WHERE id = $1 RETURNING last_login",
+		user.ID).Scan(&user.LastLogin)
+
+	h.startSession(w, r, http.StatusOK, &user)
+}
 
-	// Generate JWT
-	expiresAt := time.Now().Add(24 * time.Hour)
+// startSession - nová relácia po prihlásení alebo prijatí pozvánky
+func (h *Handler) startSession(w http.ResponseWriter, r *http.Request, status int, user *models.User) {
+	sessionID, refreshToken, err := h.sessions.Create(r.Context(), user.ID, r.UserAgent(), h.traffic.ClientIP(r))
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Session creation failed")
+		return
+	}
+	h.issueToken(w, r, status, user, sessionID, refreshToken)
+}
+
+// issueToken - podpíše krátkodobý access JWT relácie a odpovie AuthResponse
+func (h *Handler) issueToken(w http.ResponseWriter, r *http.Request, status int, user *models.User, sessionID, refreshToken string) {
+	expiresAt := time.Now().Add(time.Duration(h.cfg.AccessTokenTTL) * time.Minute)
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"sub":  user.ID,
+		"sid":  sessionID,
 		"role": user.Role,
 		"exp":  expiresAt.Unix(),
 	})
@@ -105,14 +154,24 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	h.json(w, http.StatusOK, AuthResponse{
-		Token:     tokenString,
-		ExpiresAt: expiresAt.Unix(),
-		User:      &user,
+	permissions, _ := h.roles.Permissions(r.Context(), user.Role)
+
+	h.json(w, status, AuthResponse{
+		Token:        tokenString,
+		ExpiresAt:    expiresAt.Unix(),
+		RefreshToken: refreshToken,
+		User:         user,
+		Permissions:  permissions,
 	})
 }
 
+// Register - verejná registrácia (rola viewer), vypnuteľná cez ALLOW_REGISTRATION
 func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
+	if !h.cfg.AllowRegistration {
+		h.error(w, http.StatusForbidden, "Registration is disabled")
+		return
+	}
+
 	var req struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
@@ -123,7 +182,17 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	req.Email = normalizeEmail(req.Email)
+	if msg := validateAccount(req.Email, req.Name); msg != "" {
+		h.error(w, http.StatusBadRequest, msg)
+		return
+	}
+	if err := auth.ValidatePassword(req.Password); err != nil {
+		h.error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hash, err := auth.HashPassword(req.Password)
 	if err != nil {
 		h.error(w, http.StatusInternalServerError, "Password hashing failed")
 		return
@@ -133,9 +202,9 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	var userID string
 	err = h.db.QueryRow(ctx, `
 		INSERT INTO users (email, password_hash, name, role)
-		VALUES ($1, $2, $3, 'merchant')
+		VALUES ($1, $2, $3, $4)
 		RETURNING id
-	`, req.Email, string(hash), req.Name).Scan(&userID)
+	`, req.Email, hash, req.Name, rbac.RoleViewer).Scan(&userID)
 
 	if err != nil {
 		h.error(w, http.StatusConflict, "Email already exists")
@@ -145,34 +214,56 @@ func (h *Handler) Register(w http.ResponseWriter, r *http.Request) {
 	h.json(w, http.StatusCreated, map[string]string{"id": userID})
 }
 
+// RefreshToken - {"refresh_token"}; vymení refresh token za nový a vydá nový access token
 func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
-	// Get user from context (set by auth middleware)
-	userID := r.Context().Value("user_id").(string)
-
-	var user models.User
-	err := h.db.QueryRow(r.Context(), `
-		SELECT id, email, name, role FROM users WHERE id = $1
-	`, userID).Scan(&user.ID, &user.Email, &user.Name, &user.Role)
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		h.error(w, http.StatusBadRequest, "refresh_token is required")
+		return
+	}
 
+	ctx := r.Context()
+	sessionID, userID, refreshToken, err := h.sessions.Rotate(ctx, req.RefreshToken)
+	if errors.Is(err, auth.ErrInvalidToken) || errors.Is(err, auth.ErrTokenReused) {
+		h.error(w, http.StatusUnauthorized, err.Error())
+		return
+	}
 	if err != nil {
-		h.error(w, http.StatusUnauthorized, "User not found")
+		h.error(w, http.StatusInternalServerError, "Token refresh failed")
 		return
 	}
 
-	expiresAt := time.Now().Add(24 * time.Hour)
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"sub":  user.ID,
-		"role": user.Role,
-		"exp":  expiresAt.Unix(),
-	})
+	var user models.User
+	row := h.db.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE id = $1", userID)
+	if err := scanUser(row, &user); err != nil || !user.IsActive {
+		h.sessions.Revoke(ctx, userID, sessionID, auth.RevokedDeactivated)
+		h.error(w, http.StatusUnauthorized, "Account is disabled")
+		return
+	}
 
-	tokenString, _ := token.SignedString([]byte(h.cfg.JWTSecret))
+	h.issueToken(w, r, http.StatusOK, &user, sessionID, refreshToken)
+}
 
-	h.json(w, http.StatusOK, AuthResponse{
-		Token:     tokenString,
-		ExpiresAt: expiresAt.Unix(),
-		User:      &user,
-	})
+// Logout zruší aktuálnu reláciu
+func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if _, err := h.sessions.Revoke(ctx, middleware.UserID(ctx), middleware.SessionID(ctx), auth.RevokedLogout); err != nil {
+		h.error(w, http.StatusInternalServerError, "Logout failed")
+		return
+	}
+	h.json(w, http.StatusOK, map[string]string{"status": "logged_out"})
+}
+
+// LogoutAll zruší všetky relácie používateľa vrátane aktuálnej
+func (h *Handler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if err := h.sessions.RevokeUser(ctx, middleware.UserID(ctx), "", auth.RevokedLogoutAll); err != nil {
+		h.error(w, http.StatusInternalServerError, "Logout failed")
+		return
+	}
+	h.json(w, http.StatusOK, map[string]string{"status": "logged_out"})
 }
 
 // ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
@@ -185,72 +276,144 @@ type ProductsResponse struct {
 	Page       int              `json:"page"`
 	PerPage    int              `json:"per_page"`
 	TotalPages int              `json:"total_pages"`
+
+	Facets   *models.Facets `json:"facets,omitempty"`
+	SearchID string         `json:"search_id,omitempty"` // send back with POST /search/click
 }
 
+// ListProducts - ?category= (slug alebo ID, vrátane podkategórií), ?search=, ?sort=,
+// filtre faziet (pozri parseFacetFilters)
 func (h *Handler) ListProducts(w http.ResponseWriter, r *http.Request) {
+	response, ok := h.productListing(w, r, r.URL.Query().Get("category"))
+	if !ok {
+		return
+	}
+	h.json(w, http.StatusOK, response)
+}
+
+// productListing zostaví stránku produktov; pri chybe zapíše odpoveď a vráti false
+func (h *Handler) productListing(w http.ResponseWriter, r *http.Request, category string) (*ProductsResponse, bool) {
 	ctx := r.Context()
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	params := r.URL.Query()
+	page, _ := strconv.Atoi(params.Get("page"))
 	if page < 1 {
 		page = 1
 	}
-	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+	perPage, _ := strconv.Atoi(params.Get("per_page"))
 	if perPage < 1 || perPage > 100 {
 		perPage = 24
 	}
 	offset := (page - 1) * perPage
 
-	category := r.URL.Query().Get("category")
-	search := r.URL.Query().Get("search")
-	sort := r.URL.Query().Get("sort")
-
-	// Build query
-	query := `SELECT id, slug, title, description, price, regular_price, sale_price, 
-		image_url, category_id, brand, stock_status, affiliate_url, button_text
-		FROM products WHERE is_active = true`
-	countQuery := "SELECT COUNT(*) FROM products WHERE is_active = true"
-	args := []interface{}{}
-	argCount := 1
+	searchQuery := h.searcher.Parse(ctx, params.Get("search"))
+	sort := params.Get("sort")
+	priceDroppedSince := params.Get("price_dropped_since")
+
+	filters := &sqlbuilder.Filters{}
+	filters.Add("", "products.is_active = true")
+
+	// Price drop = current price vs. highest recorded price since the given date
+	var since *time.Time
+	if sort == "biggest_drop" || priceDroppedSince != "" {
+		t := time.Now().AddDate(0, 0, -30)
+		if priceDroppedSince != "" {
+			parsed, err := parseSince(priceDroppedSince)
+			if err != nil {
+				h.error(w, http.StatusBadRequest, "Invalid price_dropped_since")
+				return nil, false
+			}
+			t = parsed
+		}
+		since = &t
+	}
+	joins := func(b *sqlbuilder.Builder) string {
+		if since == nil {
+			return ""
+		}
+		return `
+		LEFT JOIN LATERAL (
+			SELECT MAX(COALESCE(ph.sale_price, ph.price)) AS price_before
+			FROM product_price_history ph
+			WHERE ph.product_id = products.id AND ph.recorded_at >= ` + b.Arg(*since) + `
+		) pd ON true`
+	}
 
 	if category != "" {
-		query += " AND category_id = $" + strconv.Itoa(argCount)
-		countQuery += " AND category_id = $" + strconv.Itoa(argCount)
-		args = append(args, category)
-		argCount++
-	}
+		// The category and all its descendants
+		filters.Add("category", `products.category_id IN (
+			WITH RECURSIVE subtree AS (
+				SELECT id FROM categories WHERE id::text = $? OR slug = $?
+				UNION
+				SELECT c.id FROM categories c JOIN subtree s ON c.parent_id = s.id
+			)
+			SELECT id FROM subtree
+		)`, category, category)
+	}
+	if !searchQuery.Empty() {
+		filters.AddFunc("search", func(b *sqlbuilder.Builder) string {
+			where, _ := searchQuery.Match("products", b.Add(searchQuery.Args()...))
+			return where
+		})
+	}
+	if priceDroppedSince != "" {
+		filters.Add("price_drop", "pd.price_before > COALESCE(products.sale_price, products.price)")
+	}
+	selection := parseFacetFilters(filters, params)
 
-	if search != "" {
-		query += " AND title ILIKE $" + strconv.Itoa(argCount)
-		countQuery += " AND title ILIKE $" + strconv.Itoa(argCount)
-		args = append(args, "%"+search+"%")
-		argCount++
-	}
+	// Build query
+	b := &sqlbuilder.Builder{}
+	dropSelect := "NULL::numeric"
+	if since != nil {
+		dropSelect = "pd.price_before"
+	}
+	query := `SELECT products.id, products.slug, products.title, products.description,
+		products.price, products.regular_price, products.sale_price, products.image_url,
+		products.category_id, products.brand, products.stock_status, products.affiliate_url,
+		products.button_text, products.offer_count, vr.price_min, vr.price_max, ` + dropSelect + `
+		FROM products
+		LEFT JOIN LATERAL (
+			SELECT MIN(v.price) AS price_min, MAX(v.price) AS price_max
+			FROM product_variants v WHERE v.product_id = products.id AND v.is_active = true
+		) vr ON true` + joins(b) + `
+		WHERE ` + filters.Render(b)
 
 	// Order
 	switch sort {
 	case "price_asc":
-		query += " ORDER BY price ASC"
+		query += " ORDER BY products.price ASC"
 	case "price_desc":
-		query += " ORDER BY price DESC"
+		query += " ORDER BY products.price DESC"
 	case "name":
-		query += " ORDER BY title ASC"
+		query += " ORDER BY products.title ASC"
 	case "newest":
-		query += " ORDER BY created_at DESC"
+		query += " ORDER BY products.created_at DESC"
+	case "popular":
+		query += " ORDER BY products.popularity_score DESC, products.created_at DESC"
+	case "biggest_drop":
+		query += " ORDER BY (pd.price_before - COALESCE(products.sale_price, products.price)) / NULLIF(pd.price_before, 0) DESC NULLS LAST, products.created_at DESC"
 	default:
-		query += " ORDER BY created_at DESC"
+		// Searches are ordered by relevance unless a sort is requested
+		if !searchQuery.Empty() {
+			_, rank := searchQuery.Match("products", b.Add(searchQuery.Args()...))
+			query += " ORDER BY " + rank + " DESC, products.created_at DESC"
+		} else {
+			query += " ORDER BY products.created_at DESC"
+		}
 	}
 
-	query += " LIMIT $" + strconv.Itoa(argCount) + " OFFSET $" + strconv.Itoa(argCount+1)
-	args = append(args, perPage, offset)
+	query += " LIMIT " + b.Arg(perPage) + " OFFSET " + b.Arg(offset)
 
 	// Get total count
 	var total int
-	h.db.QueryRow(ctx, countQuery, args[:len(args)-2]...).Scan(&total)
+	cb := &sqlbuilder.Builder{}
+	countQuery := "SELECT COUNT(*) FROM products" + joins(cb) + " WHERE " + filters.Render(cb)
+	h.db.QueryRow(ctx, countQuery, cb.Args()...).Scan(&total)
 
 	// Get products
-	rows, err := h.db.Query(ctx, query, args...)
+	rows, err := h.db.Query(ctx, query, b.Args()...)
 	if err != nil {
 		h.error(w, http.StatusInternalServerError, "Database error")
-		return
+		return nil, false
 	}
 	defer rows.Close()
 
@@ -259,19 +422,28 @@ func (h *Handler) ListProducts(w http.ResponseWriter, r *http.Request) {
 		var p models.Product
 		rows.Scan(&p.ID, &p.Slug, &p.Title, &p.Description, &p.Price, &p.RegularPrice,
 			&p.SalePrice, &p.ImageURL, &p.CategoryID, &p.Brand, &p.StockStatus,
-			&p.AffiliateURL, &p.ButtonText)
+			&p.AffiliateURL, &p.ButtonText, &p.OfferCount, &p.PriceMin, &p.PriceMax, &p.PriceBefore)
 		products = append(products, p)
 	}
+	rows.Close()
 
 	totalPages := (total + perPage - 1) / perPage
 
-	h.json(w, http.StatusOK, ProductsResponse{
+	response := &ProductsResponse{
 		Products:   products,
 		Total:      total,
 		Page:       page,
 		PerPage:    perPage,
 		TotalPages: totalPages,
-	})
+	}
+	if params.Get("facets") != "false" {
+		response.Facets = h.productFacets(ctx, filters, joins, selection)
+	}
+	if !searchQuery.Empty() && page == 1 {
+		response.SearchID = h.logSearch(searchQuery, params.Get("search"), total, search.SourceListing)
+	}
+
+	return response, true
 }
 
 func (h *Handler) GetProduct(w http.ResponseWriter, r *http.Request) {
@@ -282,20 +454,24 @@ func (h *Handler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	err := h.db.QueryRow(ctx, `
 		SELECT id, slug, title, description, short_description, price, regular_price, sale_price,
 			ean, sku, image_url, gallery_images, category_id, category_path, brand, manufacturer,
-			stock_status, stock_quantity, attributes, affiliate_url, button_text, delivery_time
+			stock_status, stock_quantity, attributes, affiliate_url, button_text, delivery_time,
+			item_group_id, best_offer_id, offer_count
 		FROM products WHERE slug = $1 AND is_active = true
 	`, slug).Scan(&p.ID, &p.Slug, &p.Title, &p.Description, &p.ShortDescription, &p.Price,
 		&p.RegularPrice, &p.SalePrice, &p.EAN, &p.SKU, &p.ImageURL, &p.GalleryImages,
 		&p.CategoryID, &p.CategoryPath, &p.Brand, &p.Manufacturer, &p.StockStatus,
-		&p.StockQuantity, &p.Attributes, &p.AffiliateURL, &p.ButtonText, &p.DeliveryTime)
+		&p.StockQuantity, &p.Attributes, &p.AffiliateURL, &p.ButtonText, &p.DeliveryTime,
+		&p.ItemGroupID, &p.BestOfferID, &p.OfferCount)
 
 	if err != nil {
+		if h.tryRedirect(w, r, redirects.TypeProduct, slug) {
+			return
+		}
 		h.error(w, http.StatusNotFound, "Product not found")
 		return
 	}
 
-	// Increment view count
-	h.db.Exec(ctx, "UPDATE products SET view_count = view_count + 1 WHERE id = $1", p.ID)
+	h.countView(r, p.ID)
 
 	// Get category if exists
 	if p.CategoryID != nil {
@@ -308,39 +484,131 @@ func (h *Handler) GetProduct(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	h.loadVariants(ctx, &p, true)
+	h.loadOffers(ctx, &p, true)
+
 	h.json(w, http.StatusOK, p)
 }
 
-func (h *Handler) SearchProducts(w http.ResponseWriter, r *http.Request) {
-	query := r.URL.Query().Get("q")
-	if query == "" {
-		h.json(w, http.StatusOK, []models.Product{})
+// GetPriceHistory vráti históriu ceny produktu pre graf (?days=, predvolene 90)
+func (h *Handler) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	ctx := r.Context()
+
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+	if days < 1 || days > 730 {
+		days = 90
+	}
+
+	var productID string
+	var current float64
+	err := h.db.QueryRow(ctx, `
+		SELECT id, COALESCE(sale_price, price) FROM products WHERE slug = $1 AND is_active = true
+	`, slug).Scan(&productID, &current)
+	if err != nil {
+		h.error(w, http.StatusNotFound, "Product not found")
 		return
 	}
 
-	ctx := r.Context()
+	// The last change before the window is the starting point of the chart
 	rows, err := h.db.Query(ctx, `
-		SELECT id, slug, title, price, image_url
-		FROM products
-		WHERE is_active = true AND title ILIKE $1
-		ORDER BY title
-		LIMIT 10
-	`, "%"+query+"%")
-
+		SELECT price, regular_price, sale_price, stock_status, source, recorded_at
+		FROM product_price_history
+		WHERE product_id = $1 AND recorded_at >= (
+			SELECT COALESCE(MAX(recorded_at), '-infinity') FROM product_price_history
+			WHERE product_id = $1 AND recorded_at <= NOW() - make_interval(days => $2)
+		)
+		ORDER BY recorded_at
+	`, productID, days)
 	if err != nil {
-		h.error(w, http.StatusInternalServerError, "Search failed")
+		h.error(w, http.StatusInternalServerError, "Database error")
 		return
 	}
 	defer rows.Close()
 
-	products := []models.Product{}
+	points := []models.PricePoint{}
+	lowest, highest := current, current
 	for rows.Next() {
-		var p models.Product
-		rows.Scan(&p.ID, &p.Slug, &p.Title, &p.Price, &p.ImageURL)
-		products = append(products, p)
+		var pt models.PricePoint
+		rows.Scan(&pt.Price, &pt.RegularPrice, &pt.SalePrice, &pt.StockStatus, &pt.Source, &pt.RecordedAt)
+		points = append(points, pt)
+
+		price := pt.Price
+		if pt.SalePrice != nil {
+			price = *pt.SalePrice
+		}
+		if price < lowest {
+			lowest = price
+		}
+		if price > highest {
+			highest = price
+		}
+	}
+
+	h.json(w, http.StatusOK, map[string]interface{}{
+		"product_id":    productID,
+		"days":          days,
+		"current_price": current,
+		"lowest_price":  lowest,
+		"highest_price": highest,
+		"points":        points,
+	})
+}
+
+// SearchProducts - fulltextové vyhľadávanie (?q=, ?limit=, ?page=) zoradené podľa relevancie,
+// celkový počet výsledkov je v hlavičke X-Total-Count, ID vyhľadávania v X-Search-ID
+func (h *Handler) SearchProducts(w http.ResponseWriter, r *http.Request) {
+	q := h.searcher.Parse(r.Context(), r.URL.Query().Get("q"))
+	if q.Empty() {
+		h.json(w, http.StatusOK, []search.Result{})
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 50 {
+		limit = 10
+	}
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
 	}
 
-	h.json(w, http.StatusOK, products)
+	results, total, err := h.searcher.Search(r.Context(), q, limit, (page-1)*limit)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Search failed")
+		return
+	}
+
+	if page == 1 {
+		w.Header().Set("X-Search-ID", h.logSearch(q, r.URL.Query().Get("q"), total, search.SourceSearch))
+	}
+
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	h.json(w, http.StatusOK, results)
+}
+
+// SearchSuggest - návrhy pre vyhľadávacie pole (?q=, aspoň 2 znaky)
+func (h *Handler) SearchSuggest(w http.ResponseWriter, r *http.Request) {
+	q := h.searcher.Parse(r.Context(), r.URL.Query().Get("q"))
+	if len([]rune(q.Text)) < 2 {
+		h.json(w, http.StatusOK, &search.Suggestions{
+			Query:       q.Text,
+			Completions: []string{},
+			Categories:  []search.CategorySuggestion{},
+			Brands:      []search.BrandSuggestion{},
+			Products:    []search.ProductSuggestion{},
+		})
+		return
+	}
+
+	suggestions, err := h.searcher.Suggest(r.Context(), q)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Search failed")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	h.json(w, http.StatusOK, suggestions)
 }
 
 // ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
@@ -427,14 +695,23 @@ func (h *Handler) AdminGetProduct(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
 	var p models.Product
-	err := h.db.QueryRow(ctx, `SELECT * FROM products WHERE id = $1`, id).Scan(
+	err := h.db.QueryRow(ctx, `
+		SELECT id, slug, title, description, short_description, price, regular_price, sale_price,
+			currency, ean, sku, mpn, external_id, image_url, gallery_images, category_id,
+			category_path, brand, manufacturer, stock_status, stock_quantity, is_active,
+			is_featured, attributes, affiliate_url, button_text, delivery_time, feed_id,
+			feed_checksum, view_count, click_count, created_at, updated_at, item_group_id,
+			best_offer_id, offer_count, COALESCE(locked_fields, '[]'::jsonb)
+		FROM products WHERE id = $1
+	`, id).Scan(
 		&p.ID, &p.Slug, &p.Title, &p.Description, &p.ShortDescription,
 		&p.Price, &p.RegularPrice, &p.SalePrice, &p.Currency, &p.EAN, &p.SKU,
 		&p.MPN, &p.ExternalID, &p.ImageURL, &p.GalleryImages, &p.CategoryID,
 		&p.CategoryPath, &p.Brand, &p.Manufacturer, &p.StockStatus, &p.StockQuantity,
 		&p.IsActive, &p.IsFeatured, &p.Attributes, &p.AffiliateURL, &p.ButtonText,
 		&p.DeliveryTime, &p.FeedID, &p.FeedChecksum, &p.ViewCount, &p.ClickCount,
-		&p.CreatedAt, &p.UpdatedAt,
+		&p.CreatedAt, &p.UpdatedAt, &p.ItemGroupID, &p.BestOfferID, &p.OfferCount,
+		&p.LockedFields,
 	)
 
 	if err != nil {
@@ -442,6 +719,9 @@ func (h *Handler) AdminGetProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.loadVariants(ctx, &p, false)
+	h.loadOffers(ctx, &p, false)
+
 	h.json(w, http.StatusOK, p)
 }
 
@@ -454,9 +734,19 @@ func (h *Handler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 	p.ID = uuid.New().String()
-	p.Slug = generateSlug(p.Title)
+	// An explicit slug is normalized, otherwise it comes from the title
+	slugText := p.Title
+	if p.Slug != "" {
+		slugText = p.Slug
+	}
+	productSlug, err := slug.Unique(ctx, h.db, "products", slugText, "")
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to create product")
+		return
+	}
+	p.Slug = productSlug
 
-	_, err := h.db.Exec(ctx, `
+	_, err = h.db.Exec(ctx, `
 		INSERT INTO products (id, slug, title, description, short_description, price, 
 			regular_price, sale_price, ean, sku, image_url, gallery_images, category_id, 
 			brand, manufacturer, stock_status, stock_quantity, is_active, attributes,
@@ -467,6 +757,10 @@ func (h *Handler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		p.CategoryID, p.Brand, p.Manufacturer, p.StockStatus, p.StockQuantity,
 		p.IsActive, p.Attributes, p.AffiliateURL, p.ButtonText, p.DeliveryTime)
 
+	if slug.IsConflict(err) {
+		h.error(w, http.StatusConflict, "Slug already exists")
+		return
+	}
 	if err != nil {
 		h.error(w, http.StatusInternalServerError, "Failed to create product")
 		return
@@ -483,33 +777,106 @@ func (h *Handler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// locked_fields is optional, omitted keeps the current locks
+	var locked interface{}
+	if p.LockedFields != nil {
+		if field, ok := invalidProductField(p.LockedFields); !ok {
+			h.error(w, http.StatusBadRequest, "Unknown field: "+field)
+			return
+		}
+		locked, _ = json.Marshal(p.LockedFields)
+	}
+
 	ctx := r.Context()
-	_, err := h.db.Exec(ctx, `
+
+	// Slug changes only on request (explicit slug or ?regenerate_slug=true),
+	// the old slug keeps working as a redirect
+	err := h.updateWithSlug(ctx, "products", redirects.TypeProduct, id, slugChangeText(r, p.Slug, p.Title), `
 		UPDATE products SET
 			title = $2, description = $3, short_description = $4, price = $5,
 			regular_price = $6, sale_price = $7, ean = $8, sku = $9, image_url = $10,
 			gallery_images = $11, category_id = $12, brand = $13, manufacturer = $14,
 			stock_status = $15, stock_quantity = $16, is_active = $17, attributes = $18,
-			affiliate_url = $19, button_text = $20, delivery_time = $21, updated_at = NOW()
+			affiliate_url = $19, button_text = $20, delivery_time = $21,
+			locked_fields = COALESCE($22::jsonb, locked_fields), updated_at = NOW()
 		WHERE id = $1
 	`, id, p.Title, p.Description, p.ShortDescription, p.Price,
 		p.RegularPrice, p.SalePrice, p.EAN, p.SKU, p.ImageURL,
 		p.GalleryImages, p.CategoryID, p.Brand, p.Manufacturer,
 		p.StockStatus, p.StockQuantity, p.IsActive, p.Attributes,
-		p.AffiliateURL, p.ButtonText, p.DeliveryTime)
+		p.AffiliateURL, p.ButtonText, p.DeliveryTime, locked)
 
+	if errors.Is(err, pgx.ErrNoRows) {
+		h.error(w, http.StatusNotFound, "Product not found")
+		return
+	}
+	if slug.IsConflict(err) {
+		h.error(w, http.StatusConflict, "Slug already exists")
+		return
+	}
 	if err != nil {
 		h.error(w, http.StatusInternalServerError, "Failed to update product")
 		return
 	}
 
+	if err := pricehistory.Record(ctx, h.db, []string{id}, pricehistory.SourceManual); err != nil {
+		log.Printf("Price history for product %s not recorded: %v", id, err)
+	}
+
 	h.json(w, http.StatusOK, map[string]string{"status": "updated"})
 }
 
+// UpdateLockedFields nastaví polia produktu, ktoré import neprepíše
+func (h *Handler) UpdateLockedFields(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var req struct {
+		LockedFields []string `json:"locked_fields"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.LockedFields == nil {
+		req.LockedFields = []string{}
+	}
+	if field, ok := invalidProductField(req.LockedFields); !ok {
+		h.error(w, http.StatusBadRequest, "Unknown field: "+field)
+		return
+	}
+
+	ctx := r.Context()
+	locked, _ := json.Marshal(req.LockedFields)
+	result, err := h.db.Exec(ctx, `
+		UPDATE products SET locked_fields = $2, updated_at = NOW() WHERE id = $1
+	`, id, locked)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to update locked fields")
+		return
+	}
+	if result.RowsAffected() == 0 {
+		h.error(w, http.StatusNotFound, "Product not found")
+		return
+	}
+
+	h.json(w, http.StatusOK, map[string]interface{}{"status": "updated", "locked_fields": req.LockedFields})
+}
+
+// invalidProductField vráti prvé pole, ktoré nie je zamykateľným poľom produktu
+func invalidProductField(fields []string) (string, bool) {
+	for _, field := range fields {
+		if !models.IsProductField(field) {
+			return field, false
+		}
+	}
+	return "", true
+}
+
 func (h *Handler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	ctx := r.Context()
 
+	h.redirectDeletedProducts(ctx, []string{id})
+
 	_, err := h.db.Exec(ctx, "DELETE FROM products WHERE id = $1", id)
 	if err != nil {
 		h.error(w, http.StatusInternalServerError, "Failed to delete product")
@@ -537,6 +904,7 @@ func (h *Handler) BulkProductAction(w http.ResponseWriter, r *http.Request) {
 	case "deactivate":
 		h.db.Exec(ctx, "UPDATE products SET is_active = false WHERE id = ANY($1)", req.IDs)
 	case "delete":
+		h.redirectDeletedProducts(ctx, req.IDs)
 		h.db.Exec(ctx, "DELETE FROM products WHERE id = ANY($1)", req.IDs)
 	default:
 		h.error(w, http.StatusBadRequest, "Unknown action")
@@ -588,6 +956,9 @@ func (h *Handler) GetCategory(w http.ResponseWriter, r *http.Request) {
 	`, slug).Scan(&c.ID, &c.Name, &c.Slug, &c.Description, &c.ImageURL, &c.ParentID, &c.ProductCount)
 
 	if err != nil {
+		if h.tryRedirect(w, r, redirects.TypeCategory, slug) {
+			return
+		}
 		h.error(w, http.StatusNotFound, "Category not found")
 		return
 	}
@@ -595,6 +966,91 @@ func (h *Handler) GetCategory(w http.ResponseWriter, r *http.Request) {
 	h.json(w, http.StatusOK, c)
 }
 
+// CategoryProductsResponse - Produkty kategórie vrátane podkategórií
+type CategoryProductsResponse struct {
+	Category      models.Category   `json:"category"`
+	Breadcrumbs   []models.Category `json:"breadcrumbs"`
+	Subcategories []models.Category `json:"subcategories"`
+	*ProductsResponse
+}
+
+// GetCategoryProducts - produkty kategórie a všetkých jej podkategórií s drobčekovou navigáciou,
+// podporuje rovnaké parametre ako ListProducts
+func (h *Handler) GetCategoryProducts(w http.ResponseWriter, r *http.Request) {
+	slug := chi.URLParam(r, "slug")
+	ctx := r.Context()
+
+	var c models.Category
+	err := h.db.QueryRow(ctx, `
+		SELECT id, name, slug, description, image_url, parent_id, product_count
+		FROM categories WHERE slug = $1 AND is_active = true
+	`, slug).Scan(&c.ID, &c.Name, &c.Slug, &c.Description, &c.ImageURL, &c.ParentID, &c.ProductCount)
+
+	if err != nil {
+		if h.tryRedirect(w, r, redirects.TypeCategory, slug) {
+			return
+		}
+		h.error(w, http.StatusNotFound, "Category not found")
+		return
+	}
+
+	listing, ok := h.productListing(w, r, c.ID)
+	if !ok {
+		return
+	}
+
+	response := CategoryProductsResponse{
+		Category:         c,
+		Breadcrumbs:      h.categoryBreadcrumbs(ctx, c.ID),
+		Subcategories:    []models.Category{},
+		ProductsResponse: listing,
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT id, name, slug, description, image_url, parent_id, product_count, sort_order
+		FROM categories
+		WHERE parent_id = $1 AND is_active = true
+		ORDER BY sort_order, name
+	`, c.ID)
+	if err == nil {
+		defer rows.Close()
+		for rows.Next() {
+			var sub models.Category
+			rows.Scan(&sub.ID, &sub.Name, &sub.Slug, &sub.Description, &sub.ImageURL,
+				&sub.ParentID, &sub.ProductCount, &sub.SortOrder)
+			response.Subcategories = append(response.Subcategories, sub)
+		}
+	}
+
+	h.json(w, http.StatusOK, response)
+}
+
+// categoryBreadcrumbs vráti cestu od koreňa po kategóriu (vrátane nej)
+func (h *Handler) categoryBreadcrumbs(ctx context.Context, categoryID string) []models.Category {
+	crumbs := []models.Category{}
+	rows, err := h.db.Query(ctx, `
+		WITH RECURSIVE chain AS (
+			SELECT id, name, slug, parent_id, 0 AS depth FROM categories WHERE id = $1
+			UNION ALL
+			SELECT c.id, c.name, c.slug, c.parent_id, chain.depth + 1
+			FROM categories c JOIN chain ON c.id = chain.parent_id
+			WHERE chain.depth < 20
+		)
+		SELECT id, name, slug, parent_id FROM chain ORDER BY depth DESC
+	`, categoryID)
+	if err != nil {
+		return crumbs
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c models.Category
+		rows.Scan(&c.ID, &c.Name, &c.Slug, &c.ParentID)
+		crumbs = append(crumbs, c)
+	}
+	return crumbs
+}
+
 func (h *Handler) AdminListCategories(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	rows, err := h.db.Query(ctx, `
@@ -627,13 +1083,26 @@ func (h *Handler) CreateCategory(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 	c.ID = uuid.New().String()
-	c.Slug = generateSlug(c.Name)
+	slugText := c.Name
+	if c.Slug != "" {
+		slugText = c.Slug
+	}
+	categorySlug, err := slug.Unique(ctx, h.db, "categories", slugText, "")
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to create category")
+		return
+	}
+	c.Slug = categorySlug
 
-	_, err := h.db.Exec(ctx, `
+	_, err = h.db.Exec(ctx, `
 		INSERT INTO categories (id, name, slug, description, image_url, parent_id, sort_order, is_active)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`, c.ID, c.Name, c.Slug, c.Description, c.ImageURL, c.ParentID, c.SortOrder, true)
 
+	if slug.IsConflict(err) {
+		h.error(w, http.StatusConflict, "Slug already exists")
+		return
+	}
 	if err != nil {
 		h.error(w, http.StatusInternalServerError, "Failed to create category")
 		return
@@ -651,18 +1120,29 @@ func (h *Handler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	ctx := r.Context()
-	_, err := h.db.Exec(ctx, `
+	err := h.updateWithSlug(ctx, "categories", redirects.TypeCategory, id, slugChangeText(r, c.Slug, c.Name), `
 		UPDATE categories SET
 			name = $2, description = $3, image_url = $4, parent_id = $5, 
 			sort_order = $6, is_active = $7, updated_at = NOW()
 		WHERE id = $1
 	`, id, c.Name, c.Description, c.ImageURL, c.ParentID, c.SortOrder, c.IsActive)
 
+	if errors.Is(err, pgx.ErrNoRows) {
+		h.error(w, http.StatusNotFound, "Category not found")
+		return
+	}
+	if slug.IsConflict(err) {
+		h.error(w, http.StatusConflict, "Slug already exists")
+		return
+	}
 	if err != nil {
 		h.error(w, http.StatusInternalServerError, "Failed to update category")
 		return
 	}
 
+	// Moving a category changes the subtree counts of its ancestors
+	importer.UpdateCategoryCounts(ctx, h.db)
+
 	h.json(w, http.StatusOK, map[string]string{"status": "updated"})
 }
 
@@ -670,12 +1150,31 @@ func (h *Handler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	ctx := r.Context()
 
+	var categorySlug string
+	var parentSlug *string
+	h.db.QueryRow(ctx, `
+		SELECT c.slug, p.slug FROM categories c
+		LEFT JOIN categories p ON p.id = c.parent_id
+		WHERE c.id = $1
+	`, id).Scan(&categorySlug, &parentSlug)
+
 	_, err := h.db.Exec(ctx, "DELETE FROM categories WHERE id = $1", id)
 	if err != nil {
 		h.error(w, http.StatusInternalServerError, "Failed to delete category")
 		return
 	}
 
+	importer.UpdateCategoryCounts(ctx, h.db)
+
+	// Old category URLs lead to the parent category, without one they are dropped
+	if parentSlug != nil {
+		redirects.Record(ctx, h.db, redirects.TypeCategory, categorySlug, redirects.TypeCategory, *parentSlug)
+	} else if categorySlug != "" {
+		h.db.Exec(ctx, `
+			DELETE FROM slug_redirects WHERE target_type = $1 AND target_slug = $2
+		`, redirects.TypeCategory, categorySlug)
+	}
+
 	h.json(w, http.StatusOK, map[string]string{"status": "deleted"})
 }
 
@@ -686,21 +1185,39 @@ func (h *Handler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) GetDashboardStats(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	stats := make(map[string]interface{})
-
-	h.db.QueryRow(ctx, "SELECT COUNT(*) FROM products WHERE is_active = true").Scan(stats["total_products"])
-	h.db.QueryRow(ctx, "SELECT COUNT(*) FROM categories WHERE is_active = true").Scan(stats["total_categories"])
-	h.db.QueryRow(ctx, "SELECT COUNT(*) FROM feeds WHERE active = true").Scan(stats["total_feeds"])
-	h.db.QueryRow(ctx, "SELECT COALESCE(SUM(view_count), 0) FROM products").Scan(stats["total_views"])
-	h.db.QueryRow(ctx, "SELECT COALESCE(SUM(click_count), 0) FROM products").Scan(stats["total_clicks"])
+	var products, categories, feeds, conversions int
+	var views, clicks int64
+	var revenue float64
+	err := h.db.QueryRow(ctx, `
+		SELECT
+			(SELECT COUNT(*) FROM products WHERE is_active = true),
+			(SELECT COUNT(*) FROM categories WHERE is_active = true),
+			(SELECT COUNT(*) FROM feeds WHERE active = true),
+			(SELECT COALESCE(SUM(view_count), 0) FROM products),
+			(SELECT COALESCE(SUM(click_count), 0) FROM products),
+			(SELECT COUNT(*) FROM conversions WHERE status <> 'rejected'),
+			(SELECT COALESCE(SUM(commission), 0) FROM conversions WHERE status <> 'rejected')
+	`).Scan(&products, &categories, &feeds, &views, &clicks, &conversions, &revenue)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
 
-	h.json(w, http.StatusOK, stats)
+	h.json(w, http.StatusOK, map[string]interface{}{
+		"total_products":    products,
+		"total_categories":  categories,
+		"total_feeds":       feeds,
+		"total_views":       views,
+		"total_clicks":      clicks,
+		"total_conversions": conversions,
+		"total_revenue":     revenue,
+	})
 }
 
 func (h *Handler) GetRecentActivity(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	rows, err := h.db.Query(ctx, `
-		SELECT id, feed_id, started_at, finished_at, duration, created, updated, skipped, errors, status
+		SELECT id, feed_id, started_at, finished_at, duration, created, updated, skipped, filtered, errors, status
 		FROM import_history
 		ORDER BY started_at DESC
 		LIMIT 10
@@ -715,7 +1232,7 @@ func (h *Handler) GetRecentActivity(w http.ResponseWriter, r *http.Request) {
 	for rows.Next() {
 		var h models.ImportHistory
 		rows.Scan(&h.ID, &h.FeedID, &h.StartedAt, &h.FinishedAt, &h.Duration,
-			&h.Created, &h.Updated, &h.Skipped, &h.Errors, &h.Status)
+			&h.Created, &h.Updated, &h.Skipped, &h.Filtered, &h.Errors, &h.Status)
 		history = append(history, h)
 	}
 
@@ -844,6 +1361,11 @@ func (h *Handler) CreateFeed(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if msg := validateFeedSettings(f.Settings); msg != "" {
+		h.error(w, http.StatusBadRequest, msg)
+		return
+	}
+
 	ctx := r.Context()
 	f.ID = uuid.New().String()
 	f.Status = models.FeedStatusActive
@@ -874,6 +1396,10 @@ func (h *Handler) UpdateFeed(w http.ResponseWriter, r *http.Request) {
 		h.error(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
+	if msg := validateFeedSettings(f.Settings); msg != "" {
+		h.error(w, http.StatusBadRequest, msg)
+		return
+	}
 
 	ctx := r.Context()
 	_, err := h.db.Exec(ctx, `
@@ -897,16 +1423,60 @@ func (h *Handler) UpdateFeed(w http.ResponseWriter, r *http.Request) {
 	h.json(w, http.StatusOK, map[string]string{"status": "updated"})
 }
 
+// validateFeedSettings overí nastavenia feedu, ktoré sa použijú až pri preklikoch
+func validateFeedSettings(settings models.JSONMap) string {
+	var fs models.FeedSettings
+	data, _ := json.Marshal(settings)
+	if err := json.Unmarshal(data, &fs); err != nil {
+		return "Invalid feed settings: " + err.Error()
+	}
+	if err := tracking.ValidateLink(fs.AffiliateLink); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// ListAffiliateNetworks - predvolené deep-link šablóny a dostupné premenné
+func (h *Handler) ListAffiliateNetworks(w http.ResponseWriter, r *http.Request) {
+	networks := []map[string]string{}
+	for _, name := range tracking.NetworkNames() {
+		networks = append(networks, map[string]string{
+			"network":  name,
+			"template": tracking.Networks[name],
+		})
+	}
+
+	h.json(w, http.StatusOK, map[string]interface{}{
+		"networks":     networks,
+		"placeholders": tracking.Placeholders,
+	})
+}
+
 func (h *Handler) DeleteFeed(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "id")
 	ctx := r.Context()
 
-	_, err := h.db.Exec(ctx, "DELETE FROM feeds WHERE id = $1", id)
+	// Products that will lose this feed's offer
+	productIDs := []string{}
+	rows, err := h.db.Query(ctx, "SELECT product_id FROM product_offers WHERE feed_id = $1", id)
+	if err == nil {
+		for rows.Next() {
+			var productID string
+			rows.Scan(&productID)
+			productIDs = append(productIDs, productID)
+		}
+		rows.Close()
+	}
+
+	_, err = h.db.Exec(ctx, "DELETE FROM feeds WHERE id = $1", id)
 	if err != nil {
 		h.error(w, http.StatusInternalServerError, "Failed to delete feed")
 		return
 	}
 
+	importer.RefreshBestOffers(ctx, h.db, productIDs)
+	pricehistory.Record(ctx, h.db, productIDs, pricehistory.SourceImport)
+
 	h.json(w, http.StatusOK, map[string]string{"status": "deleted"})
 }
 
@@ -936,6 +1506,9 @@ func (h *Handler) StartImport(w http.ResponseWriter, r *http.Request) {
 
 	// Create import engine
 	engine := importer.NewImportEngine(h.db, &feed)
+	engine.MaxBytes = h.cfg.FeedMaxBytes
+	engine.MaxItemDropPercent = h.cfg.FeedMaxItemDropPercent
+	engine.PriceHistoryRetentionDays = h.cfg.PriceHistoryRetentionDays
 	h.importEngines.Store(feedID, engine)
 
 	// Start import in background
@@ -981,7 +1554,9 @@ func (h *Handler) GetImportHistory(w http.ResponseWriter, r *http.Request) {
 
 	rows, err := h.db.Query(ctx, `
 		SELECT id, feed_id, started_at, finished_at, duration, total_items,
-			processed, created, updated, skipped, errors, status, error_message, triggered_by
+			processed, created, updated, skipped, errors, status, error_message, triggered_by,
+			suspicious, suspicious_reason, filtered, warnings, validation_counts,
+			COALESCE(field_changes, '{}'::jsonb), COALESCE(filter_counts, '{}'::jsonb)
 		FROM import_history
 		WHERE feed_id = $1
 		ORDER BY started_at DESC
@@ -998,7 +1573,9 @@ func (h *Handler) GetImportHistory(w http.ResponseWriter, r *http.Request) {
 		var h models.ImportHistory
 		rows.Scan(&h.ID, &h.FeedID, &h.StartedAt, &h.FinishedAt, &h.Duration,
 			&h.TotalItems, &h.Processed, &h.Created, &h.Updated, &h.Skipped,
-			&h.Errors, &h.Status, &h.ErrorMessage, &h.TriggeredBy)
+			&h.Errors, &h.Status, &h.ErrorMessage, &h.TriggeredBy,
+			&h.Suspicious, &h.SuspiciousReason, &h.Filtered, &h.Warnings, &h.ValidationCounts,
+			&h.FieldChanges, &h.FilterCounts)
 		history = append(history, h)
 	}
 
@@ -1053,6 +1630,64 @@ func (h *Handler) AutoMapping(w http.ResponseWriter, r *http.Request) {
 // HELPERS
 // ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
 
+// loadVariants doplní varianty a cenové rozpätie produktu
+func (h *Handler) loadVariants(ctx context.Context, p *models.Product, activeOnly bool) {
+	rows, err := h.db.Query(ctx, `
+		SELECT id, product_id, title, price, regular_price, sale_price, ean, sku, external_id,
+			image_url, stock_status, stock_quantity, affiliate_url, attributes, is_active,
+			created_at, updated_at
+		FROM product_variants
+		WHERE product_id = $1 AND (is_active = true OR NOT $2)
+		ORDER BY price, title
+	`, p.ID, activeOnly)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var v models.ProductVariant
+		rows.Scan(&v.ID, &v.ProductID, &v.Title, &v.Price, &v.RegularPrice, &v.SalePrice,
+			&v.EAN, &v.SKU, &v.ExternalID, &v.ImageURL, &v.StockStatus, &v.StockQuantity,
+			&v.AffiliateURL, &v.Attributes, &v.IsActive, &v.CreatedAt, &v.UpdatedAt)
+		p.Variants = append(p.Variants, v)
+
+		price := v.Price
+		if p.PriceMin == nil || price < *p.PriceMin {
+			p.PriceMin = &price
+		}
+		if p.PriceMax == nil || price > *p.PriceMax {
+			p.PriceMax = &price
+		}
+	}
+}
+
+// loadOffers doplní ponuky všetkých feedov, najlepšia ponuka je prvá
+func (h *Handler) loadOffers(ctx context.Context, p *models.Product, activeOnly bool) {
+	rows, err := h.db.Query(ctx, `
+		SELECT o.id, o.product_id, o.feed_id, f.name, o.price, o.regular_price, o.sale_price,
+			o.currency, o.stock_status, o.stock_quantity, o.delivery_time, o.affiliate_url,
+			o.button_text, o.is_active, o.updated_at
+		FROM product_offers o
+		JOIN feeds f ON f.id = o.feed_id
+		WHERE o.product_id = $1 AND (o.is_active = true OR NOT $2)
+		ORDER BY (o.stock_status = 'instock') DESC, COALESCE(o.sale_price, o.price), o.updated_at DESC
+	`, p.ID, activeOnly)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var o models.ProductOffer
+		rows.Scan(&o.ID, &o.ProductID, &o.FeedID, &o.FeedName, &o.Price, &o.RegularPrice,
+			&o.SalePrice, &o.Currency, &o.StockStatus, &o.StockQuantity, &o.DeliveryTime,
+			&o.AffiliateURL, &o.ButtonText, &o.IsActive, &o.UpdatedAt)
+		o.IsBest = p.BestOfferID != nil && *p.BestOfferID == o.ID
+		p.Offers = append(p.Offers, o)
+	}
+}
+
 func buildCategoryTree(categories []models.Category, parentID *string) []*models.Category {
 	var tree []*models.Category
 
@@ -1067,26 +1702,19 @@ func buildCategoryTree(categories []models.Category, parentID *string) []*models
 	return tree
 }
 
-func generateSlug(text string) string {
-	// Simplified slug generation
-	slug := text
-	replacements := map[string]string{
-		"á": "a", "ä": "a", "č": "c", "ď": "d", "é": "e", "í": "i",
-		"ĺ": "l", "ľ": "l", "ň": "n", "ó": "o", "ô": "o", "ŕ": "r",
-		"š": "s", "ť": "t", "ú": "u", "ý": "y", "ž": "z", " ": "-",
+// slugChangeText vráti text pre slug: názov pri ?regenerate_slug=true, inak
+// slug z požiadavky (prázdny = bez zmeny, rovnaký ako aktuálny = bez zmeny)
+func slugChangeText(r *http.Request, requested, name string) string {
+	if r.URL.Query().Get("regenerate_slug") == "true" {
+		return name
 	}
-	for from, to := range replacements {
-		slug = replaceAll(slug, from, to)
-	}
-	return slug
+	return requested
 }
 
-func replaceAll(s, old, new string) string {
-	result := s
-	for i := 0; i < len(result); i++ {
-		if i+len(old) <= len(result) && result[i:i+len(old)] == old {
-			result = result[:i] + new + result[i+len(old):]
-		}
+// parseSince prijme dátum (2006-01-02) alebo RFC3339 čas
+func parseSince(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
 	}
-	return result
+	return time.Parse(time.RFC3339, value)
 }
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"eshopbuilder/internal/models"
+	"eshopbuilder/internal/redirects"
+	"eshopbuilder/internal/slug"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+// SLUG REDIRECTS
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+// tryRedirect odpovie presmerovaním, ak je slug známy starý slug
+func (h *Handler) tryRedirect(w http.ResponseWriter, r *http.Request, entityType, oldSlug string) bool {
+	rd, err := redirects.Lookup(r.Context(), h.db, entityType, oldSlug)
+	if err != nil {
+		return false
+	}
+
+	location := redirects.Path(rd.TargetType, rd.TargetSlug)
+	status := rd.StatusCode
+	if status != http.StatusFound {
+		status = http.StatusMovedPermanently
+	}
+
+	w.Header().Set("Location", location)
+	h.json(w, status, map[string]interface{}{
+		"redirect":    true,
+		"status_code": status,
+		"type":        rd.TargetType,
+		"slug":        rd.TargetSlug,
+		"location":    location,
+	})
+	return true
+}
+
+// updateWithSlug v jednej transakcii zmení slug (ak je slugText zadaný), presmeruje
+// starý slug a vykoná update; neznáme id vráti pgx.ErrNoRows
+func (h *Handler) updateWithSlug(ctx context.Context, table, entityType, id, slugText, update string, args ...any) error {
+	run := func() error {
+		tx, err := h.db.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		if slugText != "" {
+			if err := changeSlug(ctx, tx, table, entityType, id, slugText); err != nil {
+				return err
+			}
+		}
+
+		result, err := tx.Exec(ctx, update, args...)
+		if err != nil {
+			return err
+		}
+		if result.RowsAffected() == 0 {
+			return pgx.ErrNoRows
+		}
+		return tx.Commit(ctx)
+	}
+
+	// Another request may take the same slug in the meantime, pick the next one
+	for attempt := 0; ; attempt++ {
+		err := run()
+		if err == nil || !slug.IsConflict(err) || attempt == 2 {
+			return err
+		}
+	}
+}
+
+// changeSlug nastaví nový unikátny slug z textu a starý presmeruje naň
+func changeSlug(ctx context.Context, tx pgx.Tx, table, entityType, id, text string) error {
+	var current string
+	if err := tx.QueryRow(ctx, "SELECT slug FROM "+table+" WHERE id = $1 FOR UPDATE", id).Scan(&current); err != nil {
+		return err
+	}
+
+	newSlug, err := slug.Unique(ctx, tx, table, text, id)
+	if err != nil || newSlug == current {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, "UPDATE "+table+" SET slug = $2, updated_at = NOW() WHERE id = $1", id, newSlug); err != nil {
+		return err
+	}
+
+	return redirects.Record(ctx, tx, entityType, current, entityType, newSlug)
+}
+
+// redirectDeletedProducts presmeruje slugy mazaných produktov na ich kategórie
+func (h *Handler) redirectDeletedProducts(ctx context.Context, ids []string) {
+	rows, err := h.db.Query(ctx, `
+		SELECT p.slug, c.slug FROM products p
+		JOIN categories c ON c.id = p.category_id
+		WHERE p.id::text = ANY($1)
+	`, ids)
+	if err != nil {
+		return
+	}
+
+	type target struct{ product, category string }
+	targets := []target{}
+	for rows.Next() {
+		var t target
+		rows.Scan(&t.product, &t.category)
+		targets = append(targets, t)
+	}
+	rows.Close()
+
+	for _, t := range targets {
+		redirects.Record(ctx, h.db, redirects.TypeProduct, t.product, redirects.TypeCategory, t.category)
+	}
+}
+
+// ListRedirects - ?type=product|category, ?search=, stránkovanie
+func (h *Handler) ListRedirects(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage := 50
+	offset := (page - 1) * perPage
+
+	entityType := r.URL.Query().Get("type")
+	search := r.URL.Query().Get("search")
+
+	rows, err := h.db.Query(ctx, `
+		SELECT id, entity_type, old_slug, target_type, target_slug, status_code,
+			is_manual, hits, last_hit_at, created_at, updated_at
+		FROM slug_redirects
+		WHERE ($1 = '' OR entity_type = $1)
+			AND ($2 = '' OR old_slug ILIKE '%' || $2 || '%' OR target_slug ILIKE '%' || $2 || '%')
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4
+	`, entityType, search, perPage, offset)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	defer rows.Close()
+
+	list := []models.SlugRedirect{}
+	for rows.Next() {
+		var rd models.SlugRedirect
+		rows.Scan(&rd.ID, &rd.EntityType, &rd.OldSlug, &rd.TargetType, &rd.TargetSlug,
+			&rd.StatusCode, &rd.IsManual, &rd.Hits, &rd.LastHitAt, &rd.CreatedAt, &rd.UpdatedAt)
+		list = append(list, rd)
+	}
+
+	var total int
+	h.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM slug_redirects
+		WHERE ($1 = '' OR entity_type = $1)
+			AND ($2 = '' OR old_slug ILIKE '%' || $2 || '%' OR target_slug ILIKE '%' || $2 || '%')
+	`, entityType, search).Scan(&total)
+
+	h.json(w, http.StatusOK, map[string]interface{}{
+		"redirects": list,
+		"total":     total,
+		"page":      page,
+		"per_page":  perPage,
+	})
+}
+
+func (h *Handler) CreateRedirect(w http.ResponseWriter, r *http.Request) {
+	var rd models.SlugRedirect
+	if err := json.NewDecoder(r.Body).Decode(&rd); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if msg := validateRedirect(&rd); msg != "" {
+		h.error(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	ctx := r.Context()
+	err := h.db.QueryRow(ctx, `
+		INSERT INTO slug_redirects (entity_type, old_slug, target_type, target_slug, status_code, is_manual)
+		VALUES ($1, $2, $3, $4, $5, true)
+		RETURNING id, created_at, updated_at
+	`, rd.EntityType, rd.OldSlug, rd.TargetType, rd.TargetSlug, rd.StatusCode).Scan(&rd.ID, &rd.CreatedAt, &rd.UpdatedAt)
+	if err != nil {
+		h.error(w, http.StatusConflict, "Redirect for this slug already exists")
+		return
+	}
+	rd.IsManual = true
+
+	h.json(w, http.StatusCreated, rd)
+}
+
+func (h *Handler) UpdateRedirect(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	var rd models.SlugRedirect
+	if err := json.NewDecoder(r.Body).Decode(&rd); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if msg := validateRedirect(&rd); msg != "" {
+		h.error(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	ctx := r.Context()
+	result, err := h.db.Exec(ctx, `
+		UPDATE slug_redirects SET
+			entity_type = $2, old_slug = $3, target_type = $4, target_slug = $5,
+			status_code = $6, is_manual = true, updated_at = NOW()
+		WHERE id = $1
+	`, id, rd.EntityType, rd.OldSlug, rd.TargetType, rd.TargetSlug, rd.StatusCode)
+	if err != nil {
+		h.error(w, http.StatusConflict, "Redirect for this slug already exists")
+		return
+	}
+	if result.RowsAffected() == 0 {
+		h.error(w, http.StatusNotFound, "Redirect not found")
+		return
+	}
+
+	h.json(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+func (h *Handler) DeleteRedirect(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	ctx := r.Context()
+
+	_, err := h.db.Exec(ctx, "DELETE FROM slug_redirects WHERE id = $1", id)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to delete redirect")
+		return
+	}
+
+	h.json(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func validateRedirect(rd *models.SlugRedirect) string {
+	validType := func(t string) bool { return t == redirects.TypeProduct || t == redirects.TypeCategory }
+
+	if rd.TargetType == "" {
+		rd.TargetType = rd.EntityType
+	}
+	if !validType(rd.EntityType) || !validType(rd.TargetType) {
+		return "entity_type and target_type must be product or category"
+	}
+	if rd.OldSlug == "" || rd.TargetSlug == "" {
+		return "old_slug and target_slug are required"
+	}
+	if rd.EntityType == rd.TargetType && rd.OldSlug == rd.TargetSlug {
+		return "Redirect points to itself"
+	}
+	if rd.StatusCode == 0 {
+		rd.StatusCode = http.StatusMovedPermanently
+	}
+	if rd.StatusCode != http.StatusMovedPermanently && rd.StatusCode != http.StatusFound {
+		return "status_code must be 301 or 302"
+	}
+	return ""
+}
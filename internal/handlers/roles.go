@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+
+	"eshopbuilder/internal/middleware"
+	"eshopbuilder/internal/models"
+	"eshopbuilder/internal/rbac"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+// ROLES & PERMISSIONS
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+// ListPermissions - všetky oprávnenia, ktoré možno priradiť roli
+func (h *Handler) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	h.json(w, http.StatusOK, rbac.Permissions)
+}
+
+func (h *Handler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.Query(r.Context(), `
+		SELECT ro.name, ro.description, ro.permissions, ro.is_system, ro.created_at, ro.updated_at,
+			(SELECT COUNT(*) FROM users u WHERE u.role = ro.name)
+		FROM roles ro
+		ORDER BY ro.is_system DESC, ro.name
+	`)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		var role models.Role
+		rows.Scan(&role.Name, &role.Description, &role.Permissions, &role.IsSystem,
+			&role.CreatedAt, &role.UpdatedAt, &role.UserCount)
+		roles = append(roles, role)
+	}
+
+	h.json(w, http.StatusOK, roles)
+}
+
+func (h *Handler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	var role models.Role
+	if err := json.NewDecoder(r.Body).Decode(&role); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if !roleNamePattern.MatchString(role.Name) {
+		h.error(w, http.StatusBadRequest, "name must be 2-50 lowercase letters, digits, - or _")
+		return
+	}
+	if msg := validatePermissions(role.Permissions); msg != "" {
+		h.error(w, http.StatusBadRequest, msg)
+		return
+	}
+	if status, msg := h.checkGrantable(r, role.Permissions); status != 0 {
+		h.error(w, status, msg)
+		return
+	}
+	if role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+
+	err := h.db.QueryRow(r.Context(), `
+		INSERT INTO roles (name, description, permissions) VALUES ($1, $2, $3)
+		RETURNING created_at, updated_at
+	`, role.Name, role.Description, role.Permissions).Scan(&role.CreatedAt, &role.UpdatedAt)
+	if err != nil {
+		h.error(w, http.StatusConflict, "Role already exists")
+		return
+	}
+
+	h.roles.Invalidate()
+	h.json(w, http.StatusCreated, role)
+}
+
+// UpdateRole - popis a oprávnenia; oprávnenia roly admin sa meniť nedajú
+func (h *Handler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	var role models.Role
+	if err := json.NewDecoder(r.Body).Decode(&role); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if name == rbac.RoleAdmin {
+		h.error(w, http.StatusForbidden, "The admin role cannot be changed")
+		return
+	}
+	if msg := validatePermissions(role.Permissions); msg != "" {
+		h.error(w, http.StatusBadRequest, msg)
+		return
+	}
+	if status, msg := h.checkGrantable(r, role.Permissions); status != 0 {
+		h.error(w, status, msg)
+		return
+	}
+	if role.Permissions == nil {
+		role.Permissions = []string{}
+	}
+
+	result, err := h.db.Exec(r.Context(), `
+		UPDATE roles SET description = $2, permissions = $3, updated_at = NOW() WHERE name = $1
+	`, name, role.Description, role.Permissions)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to update role")
+		return
+	}
+	if result.RowsAffected() == 0 {
+		h.error(w, http.StatusNotFound, "Role not found")
+		return
+	}
+
+	h.roles.Invalidate()
+	h.apiKeys.Invalidate()
+	h.json(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+// DeleteRole - len vlastné roly bez používateľov
+func (h *Handler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	ctx := r.Context()
+
+	var isSystem bool
+	var users int
+	err := h.db.QueryRow(ctx, `
+		SELECT is_system, (SELECT COUNT(*) FROM users WHERE role = $1) FROM roles WHERE name = $1
+	`, name).Scan(&isSystem, &users)
+	if err != nil {
+		h.error(w, http.StatusNotFound, "Role not found")
+		return
+	}
+	if isSystem {
+		h.error(w, http.StatusForbidden, "Built-in roles cannot be deleted")
+		return
+	}
+	if users > 0 {
+		h.error(w, http.StatusConflict, "Role is assigned to users")
+		return
+	}
+
+	if _, err := h.db.Exec(ctx, "DELETE FROM roles WHERE name = $1", name); err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to delete role")
+		return
+	}
+
+	h.roles.Invalidate()
+	h.apiKeys.Invalidate()
+	h.json(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// UpdateUserRole - {"role": "editor"}; posledný aktívny admin nemôže prísť o rolu
+func (h *Handler) UpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	ctx := r.Context()
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if status, msg := h.checkManageableUser(r, id); status != 0 {
+		h.error(w, status, msg)
+		return
+	}
+	if status, msg := h.checkAssignableRole(r, req.Role); status != 0 {
+		h.error(w, status, msg)
+		return
+	}
+
+	if req.Role != rbac.RoleAdmin && h.isLastAdmin(r, id) {
+		h.error(w, http.StatusConflict, "Cannot remove the last active admin")
+		return
+	}
+
+	result, err := h.db.Exec(ctx, "UPDATE users SET role = $2, updated_at = NOW() WHERE id = $1", id, req.Role)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to update user")
+		return
+	}
+	if result.RowsAffected() == 0 {
+		h.error(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	h.sessions.Forget(id)
+	h.apiKeys.Invalidate()
+	h.json(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+// isLastAdmin - používateľ je jediný aktívny admin
+func (h *Handler) isLastAdmin(r *http.Request, userID string) bool {
+	var last bool
+	h.db.QueryRow(r.Context(), `
+		SELECT EXISTS (SELECT 1 FROM users WHERE id::text = $1 AND role = $2 AND is_active = true)
+			AND (SELECT COUNT(*) FROM users WHERE role = $2 AND is_active = true) = 1
+	`, userID, rbac.RoleAdmin).Scan(&last)
+	return last
+}
+
+// checkGrantable - volajúci môže rozdať len oprávnenia, ktoré sám má; vráti HTTP
+// status a chybu alebo 0
+func (h *Handler) checkGrantable(r *http.Request, permissions []string) (int, string) {
+	granted, err := middleware.Permissions(r.Context(), h.roles)
+	if err != nil {
+		return http.StatusInternalServerError, "Permission check failed"
+	}
+	for _, permission := range permissions {
+		if !rbac.Allows(granted, permission) {
+			return http.StatusForbidden, "You cannot grant permission " + permission
+		}
+	}
+	return 0, ""
+}
+
+func validatePermissions(permissions []string) string {
+	for _, permission := range permissions {
+		if !rbac.Valid(permission) {
+			return "Unknown permission " + permission
+		}
+	}
+	return ""
+}
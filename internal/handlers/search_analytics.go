@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"eshopbuilder/internal/models"
+	"eshopbuilder/internal/search"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+// SEARCH ANALYTICS
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+// logSearch zaznamená vyhľadávanie na pozadí a vráti jeho ID pre hlásenie kliku
+func (h *Handler) logSearch(q search.Query, raw string, total int, source string) string {
+	id := uuid.New().String()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		h.searcher.Log(ctx, id, raw, total, source)
+		if source == search.SourceSearch {
+			h.searcher.RecordQuery(ctx, q, raw, total)
+		}
+	}()
+	return id
+}
+
+// SearchClick - storefront hlási klik na produkt z výsledkov {search_id, product_id}
+func (h *Handler) SearchClick(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SearchID  string `json:"search_id"`
+		ProductID string `json:"product_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+	if _, err := uuid.Parse(req.SearchID); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid search_id")
+		return
+	}
+	if _, err := uuid.Parse(req.ProductID); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid product_id")
+		return
+	}
+
+	recorded, err := h.searcher.Click(r.Context(), req.SearchID, req.ProductID)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	h.json(w, http.StatusOK, map[string]bool{"recorded": recorded})
+}
+
+// GetTopSearchQueries - najčastejšie dotazy (?days=30, ?limit=50) s CTR
+func (h *Handler) GetTopSearchQueries(w http.ResponseWriter, r *http.Request) {
+	days, limit := analyticsRange(r)
+
+	list, err := h.searchQueryStats(r.Context(), "", days, limit)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	h.json(w, http.StatusOK, map[string]interface{}{
+		"days":    days,
+		"queries": list,
+	})
+}
+
+// GetZeroResultQueries - dotazy bez výsledkov (?days=30, ?limit=50), kandidáti na synonymá
+func (h *Handler) GetZeroResultQueries(w http.ResponseWriter, r *http.Request) {
+	days, limit := analyticsRange(r)
+
+	list, err := h.searchQueryStats(r.Context(), "result_count = 0", days, limit)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+
+	h.json(w, http.StatusOK, map[string]interface{}{
+		"days":    days,
+		"queries": list,
+	})
+}
+
+func (h *Handler) searchQueryStats(ctx context.Context, cond string, days, limit int) ([]models.SearchQueryStats, error) {
+	if cond != "" {
+		cond = " AND " + cond
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT query, (array_agg(raw_query ORDER BY created_at DESC))[1], COUNT(*),
+			COALESCE(AVG(result_count), 0), COUNT(clicked_product_id), MAX(created_at)
+		FROM search_log
+		WHERE created_at >= NOW() - make_interval(days => $1)`+cond+`
+		GROUP BY query
+		ORDER BY COUNT(*) DESC, MAX(created_at) DESC
+		LIMIT $2
+	`, days, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []models.SearchQueryStats{}
+	for rows.Next() {
+		var s models.SearchQueryStats
+		var raw *string
+		if err := rows.Scan(&s.Query, &raw, &s.Searches, &s.AvgResults, &s.Clicks, &s.LastSearchedAt); err != nil {
+			return nil, err
+		}
+		if raw != nil {
+			s.RawQuery = *raw
+		}
+		if s.Searches > 0 {
+			s.CTR = float64(s.Clicks) / float64(s.Searches)
+		}
+		list = append(list, s)
+	}
+	return list, rows.Err()
+}
+
+func analyticsRange(r *http.Request) (days, limit int) {
+	days, _ = strconv.Atoi(r.URL.Query().Get("days"))
+	if days < 1 || days > 365 {
+		days = 30
+	}
+	limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+	return days, limit
+}
+
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+// SEARCH SYNONYMS & STOPWORDS
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+func (h *Handler) ListSynonyms(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.Query(r.Context(), `
+		SELECT id, terms, created_at, updated_at FROM search_synonyms ORDER BY terms[1]
+	`)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	defer rows.Close()
+
+	list := []models.SearchSynonym{}
+	for rows.Next() {
+		var s models.SearchSynonym
+		rows.Scan(&s.ID, &s.Terms, &s.CreatedAt, &s.UpdatedAt)
+		list = append(list, s)
+	}
+
+	h.json(w, http.StatusOK, list)
+}
+
+// CreateSynonym - {"terms": ["mobil", "telefon", "smartfón"]}
+func (h *Handler) CreateSynonym(w http.ResponseWriter, r *http.Request) {
+	var s models.SearchSynonym
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+	if msg := validateSynonym(&s); msg != "" {
+		h.error(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	err := h.db.QueryRow(r.Context(), `
+		INSERT INTO search_synonyms (terms) VALUES ($1) RETURNING id, created_at, updated_at
+	`, s.Terms).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to create synonym")
+		return
+	}
+
+	h.searcher.InvalidateDictionary()
+	h.json(w, http.StatusCreated, s)
+}
+
+func (h *Handler) UpdateSynonym(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var s models.SearchSynonym
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+	if msg := validateSynonym(&s); msg != "" {
+		h.error(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	result, err := h.db.Exec(r.Context(), `
+		UPDATE search_synonyms SET terms = $2, updated_at = NOW() WHERE id = $1
+	`, id, s.Terms)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to update synonym")
+		return
+	}
+	if result.RowsAffected() == 0 {
+		h.error(w, http.StatusNotFound, "Synonym not found")
+		return
+	}
+
+	h.searcher.InvalidateDictionary()
+	h.json(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+func (h *Handler) DeleteSynonym(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	_, err := h.db.Exec(r.Context(), "DELETE FROM search_synonyms WHERE id = $1", id)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to delete synonym")
+		return
+	}
+
+	h.searcher.InvalidateDictionary()
+	h.json(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+func validateSynonym(s *models.SearchSynonym) string {
+	terms := []string{}
+	seen := make(map[string]bool)
+	for _, term := range s.Terms {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if term == "" || seen[term] {
+			continue
+		}
+		if len(term) > 100 {
+			return "Terms must be at most 100 characters"
+		}
+		seen[term] = true
+		terms = append(terms, term)
+	}
+	if len(terms) < 2 {
+		return "At least two different terms are required"
+	}
+	s.Terms = terms
+	return ""
+}
+
+func (h *Handler) ListStopwords(w http.ResponseWriter, r *http.Request) {
+	rows, err := h.db.Query(r.Context(), "SELECT word FROM search_stopwords ORDER BY word")
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	defer rows.Close()
+
+	words := []string{}
+	for rows.Next() {
+		var word string
+		rows.Scan(&word)
+		words = append(words, word)
+	}
+
+	h.json(w, http.StatusOK, words)
+}
+
+// CreateStopwords - {"words": ["a", "na", "pre"]}
+func (h *Handler) CreateStopwords(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Words []string `json:"words"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request")
+		return
+	}
+
+	added := 0
+	for _, word := range req.Words {
+		word = strings.ToLower(strings.TrimSpace(word))
+		if word == "" || len(word) > 100 || strings.ContainsAny(word, " \t") {
+			continue
+		}
+		result, err := h.db.Exec(r.Context(), `
+			INSERT INTO search_stopwords (word) VALUES ($1) ON CONFLICT DO NOTHING
+		`, word)
+		if err != nil {
+			h.error(w, http.StatusInternalServerError, "Failed to add stopwords")
+			return
+		}
+		added += int(result.RowsAffected())
+	}
+
+	h.searcher.InvalidateDictionary()
+	h.json(w, http.StatusOK, map[string]int{"added": added})
+}
+
+func (h *Handler) DeleteStopword(w http.ResponseWriter, r *http.Request) {
+	word := chi.URLParam(r, "word")
+
+	_, err := h.db.Exec(r.Context(), "DELETE FROM search_stopwords WHERE word = $1", strings.ToLower(word))
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to delete stopword")
+		return
+	}
+
+	h.searcher.InvalidateDictionary()
+	h.json(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"eshopbuilder/internal/traffic"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+// TRAFFIC FILTERING
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+// countView započíta zobrazenie produktu len pri ľudskej návšteve
+func (h *Handler) countView(r *http.Request, productID string) {
+	event := traffic.Event{
+		Kind:      traffic.KindView,
+		ProductID: productID,
+		IP:        h.traffic.ClientIP(r),
+		UserAgent: r.UserAgent(),
+		Referrer:  r.Referer(),
+	}
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		event.SessionID = c.Value
+	}
+
+	verdict := h.traffic.Classify(event, "")
+	if !verdict.Human() {
+		h.recordFiltered(event, verdict)
+		return
+	}
+
+	h.stats.View(productID)
+}
+
+// recordFiltered započíta odfiltrovanú udalosť do dávky na zápis
+func (h *Handler) recordFiltered(event traffic.Event, verdict traffic.Verdict) {
+	h.filtered.Record(event, verdict.Reason)
+}
+
+// GetFilteredTraffic - súhrn odfiltrovaných zobrazení a preklikov (?days=30, ?limit= top user agentov)
+func (h *Handler) GetFilteredTraffic(w http.ResponseWriter, r *http.Request) {
+	days, limit := analyticsRange(r)
+	ctx := r.Context()
+
+	summary := []map[string]interface{}{}
+	rows, err := h.db.Query(ctx, `
+		SELECT kind, reason, SUM(hits) FROM filtered_traffic
+		WHERE created_at >= NOW() - make_interval(days => $1)
+		GROUP BY kind, reason
+		ORDER BY kind, SUM(hits) DESC
+	`, days)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	for rows.Next() {
+		var kind, reason string
+		var count int
+		rows.Scan(&kind, &reason, &count)
+		summary = append(summary, map[string]interface{}{"kind": kind, "reason": reason, "count": count})
+	}
+	rows.Close()
+
+	agents := []map[string]interface{}{}
+	rows, err = h.db.Query(ctx, `
+		SELECT COALESCE(user_agent, ''), SUM(hits) FROM filtered_traffic
+		WHERE created_at >= NOW() - make_interval(days => $1)
+		GROUP BY 1
+		ORDER BY 2 DESC
+		LIMIT $2
+	`, days, limit)
+	if err == nil {
+		for rows.Next() {
+			var agent string
+			var count int
+			rows.Scan(&agent, &count)
+			agents = append(agents, map[string]interface{}{"user_agent": agent, "count": count})
+		}
+		rows.Close()
+	}
+
+	h.json(w, http.StatusOK, map[string]interface{}{
+		"days":            days,
+		"summary":         summary,
+		"top_user_agents": agents,
+	})
+}
+
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+// PRODUCT STATS
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+// GetProductStats - denné zobrazenia a preklíky produktu (?days=30), dni bez návštev sú nulové
+func (h *Handler) GetProductStats(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	days, _ := analyticsRange(r)
+	ctx := r.Context()
+
+	var popularity float64
+	if err := h.db.QueryRow(ctx, "SELECT COALESCE(popularity_score, 0) FROM products WHERE id = $1", id).Scan(&popularity); err != nil {
+		h.error(w, http.StatusNotFound, "Product not found")
+		return
+	}
+
+	rows, err := h.db.Query(ctx, `
+		SELECT d.day::date, COALESCE(s.views, 0), COALESCE(s.clicks, 0)
+		FROM generate_series(CURRENT_DATE - ($2::int - 1), CURRENT_DATE, interval '1 day') AS d(day)
+		LEFT JOIN product_stats_daily s ON s.product_id = $1 AND s.day = d.day::date
+		ORDER BY d.day
+	`, id, days)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	defer rows.Close()
+
+	points := []map[string]interface{}{}
+	totalViews, totalClicks := 0, 0
+	for rows.Next() {
+		var day time.Time
+		var views, clicks int
+		rows.Scan(&day, &views, &clicks)
+		totalViews += views
+		totalClicks += clicks
+		points = append(points, map[string]interface{}{
+			"day":    day.Format("2006-01-02"),
+			"views":  views,
+			"clicks": clicks,
+		})
+	}
+
+	h.json(w, http.StatusOK, map[string]interface{}{
+		"product_id":       id,
+		"days":             days,
+		"views":            totalViews,
+		"clicks":           totalClicks,
+		"popularity_score": popularity,
+		"points":           points,
+	})
+}
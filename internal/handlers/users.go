@@ -0,0 +1,761 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	"eshopbuilder/internal/auth"
+	"eshopbuilder/internal/middleware"
+	"eshopbuilder/internal/models"
+	"eshopbuilder/internal/rbac"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+)
+
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+// USER MANAGEMENT
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+type UsersResponse struct {
+	Users      []models.User `json:"users"`
+	Total      int           `json:"total"`
+	Page       int           `json:"page"`
+	PerPage    int           `json:"per_page"`
+	TotalPages int           `json:"total_pages"`
+}
+
+const userColumns = "id, email, name, role, is_active, created_at, updated_at, last_login"
+
+func scanUser(row pgx.Row, u *models.User) error {
+	return row.Scan(&u.ID, &u.Email, &u.Name, &u.Role, &u.IsActive, &u.CreatedAt, &u.UpdatedAt, &u.LastLogin)
+}
+
+// ListUsers - ?search= (e-mail alebo meno), ?role=, ?status=active|inactive, stránkovanie
+func (h *Handler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page < 1 {
+		page = 1
+	}
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if perPage < 1 || perPage > 100 {
+		perPage = 50
+	}
+	offset := (page - 1) * perPage
+
+	where := " WHERE 1=1"
+	args := []interface{}{}
+	argCount := 1
+
+	if search := r.URL.Query().Get("search"); search != "" {
+		where += " AND (email ILIKE $" + strconv.Itoa(argCount) + " OR name ILIKE $" + strconv.Itoa(argCount) + ")"
+		args = append(args, "%"+search+"%")
+		argCount++
+	}
+	if role := r.URL.Query().Get("role"); role != "" {
+		where += " AND role = $" + strconv.Itoa(argCount)
+		args = append(args, role)
+		argCount++
+	}
+	switch r.URL.Query().Get("status") {
+	case "active":
+		where += " AND is_active = true"
+	case "inactive":
+		where += " AND is_active = false"
+	}
+
+	var total int
+	h.db.QueryRow(ctx, "SELECT COUNT(*) FROM users"+where, args...).Scan(&total)
+
+	query := "SELECT " + userColumns + " FROM users" + where +
+		" ORDER BY created_at DESC LIMIT $" + strconv.Itoa(argCount) + " OFFSET $" + strconv.Itoa(argCount+1)
+	rows, err := h.db.Query(ctx, query, append(args, perPage, offset)...)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var u models.User
+		scanUser(rows, &u)
+		users = append(users, u)
+	}
+
+	h.json(w, http.StatusOK, UsersResponse{
+		Users:      users,
+		Total:      total,
+		Page:       page,
+		PerPage:    perPage,
+		TotalPages: (total + perPage - 1) / perPage,
+	})
+}
+
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+	var user models.User
+	row := h.db.QueryRow(r.Context(), "SELECT "+userColumns+" FROM users WHERE id::text = $1", chi.URLParam(r, "id"))
+	if err := scanUser(row, &user); err != nil {
+		h.error(w, http.StatusNotFound, "User not found")
+		return
+	}
+	h.json(w, http.StatusOK, user)
+}
+
+// CreateUser - priame založenie účtu s heslom (bez pozvánky)
+func (h *Handler) CreateUser(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email    string `json:"email"`
+		Name     string `json:"name"`
+		Password string `json:"password"`
+		Role     string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Email = normalizeEmail(req.Email)
+	if req.Role == "" {
+		req.Role = rbac.RoleViewer
+	}
+	if msg := validateAccount(req.Email, req.Name); msg != "" {
+		h.error(w, http.StatusBadRequest, msg)
+		return
+	}
+	if err := auth.ValidatePassword(req.Password); err != nil {
+		h.error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if status, msg := h.checkAssignableRole(r, req.Role); status != 0 {
+		h.error(w, status, msg)
+		return
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Password hashing failed")
+		return
+	}
+
+	var user models.User
+	row := h.db.QueryRow(r.Context(), `
+		INSERT INTO users (email, password_hash, name, role)
+		VALUES ($1, $2, $3, $4)
+		RETURNING `+userColumns, req.Email, hash, req.Name, req.Role)
+	if err := scanUser(row, &user); err != nil {
+		h.error(w, http.StatusConflict, "Email already exists")
+		return
+	}
+
+	h.json(w, http.StatusCreated, user)
+}
+
+// UpdateUser - čiastočná zmena e-mailu, mena, roly a aktivity
+func (h *Handler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	ctx := r.Context()
+
+	var req struct {
+		Email    *string `json:"email"`
+		Name     *string `json:"name"`
+		Role     *string `json:"role"`
+		IsActive *bool   `json:"is_active"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var user models.User
+	row := h.db.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE id::text = $1", id)
+	if err := scanUser(row, &user); err != nil {
+		h.error(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if status, msg := h.checkManageableUser(r, user.ID); status != 0 {
+		h.error(w, status, msg)
+		return
+	}
+
+	if req.Email != nil {
+		user.Email = normalizeEmail(*req.Email)
+	}
+	if req.Name != nil {
+		user.Name = strings.TrimSpace(*req.Name)
+	}
+	if msg := validateAccount(user.Email, user.Name); msg != "" {
+		h.error(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	demoted := req.Role != nil && *req.Role != rbac.RoleAdmin
+	deactivated := req.IsActive != nil && !*req.IsActive
+	if deactivated && user.ID == middleware.UserID(ctx) {
+		h.error(w, http.StatusBadRequest, "You cannot deactivate your own account")
+		return
+	}
+	if (demoted || deactivated) && h.isLastAdmin(r, user.ID) {
+		h.error(w, http.StatusConflict, "Cannot remove the last active admin")
+		return
+	}
+	if req.Role != nil && *req.Role != user.Role {
+		if status, msg := h.checkAssignableRole(r, *req.Role); status != 0 {
+			h.error(w, status, msg)
+			return
+		}
+		user.Role = *req.Role
+	}
+	if req.IsActive != nil {
+		user.IsActive = *req.IsActive
+	}
+
+	row = h.db.QueryRow(ctx, `
+		UPDATE users SET email = $2, name = $3, role = $4, is_active = $5, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+userColumns, user.ID, user.Email, user.Name, user.Role, user.IsActive)
+	if err := scanUser(row, &user); err != nil {
+		h.error(w, http.StatusConflict, "Email already exists")
+		return
+	}
+
+	if deactivated {
+		h.sessions.RevokeUser(ctx, user.ID, "", auth.RevokedDeactivated)
+	} else {
+		h.sessions.Forget(user.ID)
+	}
+	h.apiKeys.Invalidate()
+
+	h.json(w, http.StatusOK, user)
+}
+
+func (h *Handler) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if id == middleware.UserID(r.Context()) {
+		h.error(w, http.StatusBadRequest, "You cannot delete your own account")
+		return
+	}
+	if status, msg := h.checkManageableUser(r, id); status != 0 {
+		h.error(w, status, msg)
+		return
+	}
+	if h.isLastAdmin(r, id) {
+		h.error(w, http.StatusConflict, "Cannot remove the last active admin")
+		return
+	}
+
+	result, err := h.db.Exec(r.Context(), "DELETE FROM users WHERE id::text = $1", id)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to delete user")
+		return
+	}
+	if result.RowsAffected() == 0 {
+		h.error(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	h.sessions.Forget(id)
+	h.apiKeys.Invalidate()
+	h.json(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// ResetUserPassword - {"password": "..."}; bez hesla vygeneruje dočasné a vráti ho
+func (h *Handler) ResetUserPassword(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var req struct {
+		Password string `json:"password"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			h.error(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+	if status, msg := h.checkManageableUser(r, id); status != 0 {
+		h.error(w, status, msg)
+		return
+	}
+
+	generated := req.Password == ""
+	if generated {
+		token, _, err := auth.NewToken()
+		if err != nil {
+			h.error(w, http.StatusInternalServerError, "Password generation failed")
+			return
+		}
+		req.Password = token[:16]
+	}
+	if err := auth.ValidatePassword(req.Password); err != nil {
+		h.error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Password hashing failed")
+		return
+	}
+
+	result, err := h.db.Exec(r.Context(), `
+		UPDATE users SET password_hash = $2, updated_at = NOW() WHERE id::text = $1
+	`, id, hash)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to reset password")
+		return
+	}
+	if result.RowsAffected() == 0 {
+		h.error(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	// Whoever knew the old password is logged out everywhere
+	h.sessions.RevokeUser(r.Context(), id, "", auth.RevokedPasswordChange)
+
+	response := map[string]string{"status": "updated"}
+	if generated {
+		response["password"] = req.Password
+	}
+	h.json(w, http.StatusOK, response)
+}
+
+// checkAssignableRole - rola musí existovať a volajúci musí mať všetky jej oprávnenia,
+// inak by si mohol pridať vyššie práva; vráti HTTP status a chybu alebo 0
+func (h *Handler) checkAssignableRole(r *http.Request, role string) (int, string) {
+	ctx := r.Context()
+
+	var exists bool
+	h.db.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM roles WHERE name = $1)", role).Scan(&exists)
+	if !exists {
+		return http.StatusBadRequest, "Unknown role"
+	}
+
+	wanted, err := h.roles.Permissions(ctx, role)
+	if err != nil {
+		return http.StatusInternalServerError, "Permission check failed"
+	}
+	if status, _ := h.checkGrantable(r, wanted); status != 0 {
+		return status, "You cannot assign a role with permissions you do not have"
+	}
+	return 0, ""
+}
+
+// checkManageableUser - meniť účet (heslo, e-mail, rolu, aktivitu) môže len volajúci,
+// ktorý má všetky oprávnenia jeho súčasnej roly; vráti HTTP status a chybu alebo 0
+func (h *Handler) checkManageableUser(r *http.Request, userID string) (int, string) {
+	ctx := r.Context()
+
+	var role string
+	if err := h.db.QueryRow(ctx, "SELECT role FROM users WHERE id::text = $1", userID).Scan(&role); err != nil {
+		return http.StatusNotFound, "User not found"
+	}
+	permissions, err := h.roles.Permissions(ctx, role)
+	if err != nil {
+		return http.StatusInternalServerError, "Permission check failed"
+	}
+	if status, _ := h.checkGrantable(r, permissions); status != 0 {
+		return status, "You cannot manage a user with permissions you do not have"
+	}
+	return 0, ""
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// validateAccount overí e-mail a meno účtu
+func validateAccount(email, name string) string {
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return "Invalid email address"
+	}
+	if strings.TrimSpace(name) == "" {
+		return "name is required"
+	}
+	return ""
+}
+
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+// INVITATIONS
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+// ListInvitations - ?status=pending|accepted|expired
+func (h *Handler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	query := `
+		SELECT i.id, i.email, i.name, i.role, i.invited_by, u.name, i.expires_at, i.accepted_at, i.created_at
+		FROM user_invitations i
+		LEFT JOIN users u ON u.id = i.invited_by
+		WHERE 1=1`
+	switch r.URL.Query().Get("status") {
+	case "pending":
+		query += " AND i.accepted_at IS NULL AND i.expires_at > NOW()"
+	case "accepted":
+		query += " AND i.accepted_at IS NOT NULL"
+	case "expired":
+		query += " AND i.accepted_at IS NULL AND i.expires_at <= NOW()"
+	}
+	query += " ORDER BY i.created_at DESC LIMIT 500"
+
+	rows, err := h.db.Query(r.Context(), query)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	defer rows.Close()
+
+	invitations := []models.UserInvitation{}
+	for rows.Next() {
+		var inv models.UserInvitation
+		rows.Scan(&inv.ID, &inv.Email, &inv.Name, &inv.Role, &inv.InvitedBy, &inv.InvitedByName,
+			&inv.ExpiresAt, &inv.AcceptedAt, &inv.CreatedAt)
+		invitations = append(invitations, inv)
+	}
+
+	h.json(w, http.StatusOK, invitations)
+}
+
+// CreateInvitation - {"email", "name", "role"}; token sa vráti len v tejto odpovedi,
+// staršie nepoužité pozvánky na rovnaký e-mail prestanú platiť
+func (h *Handler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req struct {
+		Email string  `json:"email"`
+		Name  *string `json:"name"`
+		Role  string  `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.Email = normalizeEmail(req.Email)
+	if req.Role == "" {
+		req.Role = rbac.RoleViewer
+	}
+	if addr, err := mail.ParseAddress(req.Email); err != nil || addr.Address != req.Email {
+		h.error(w, http.StatusBadRequest, "Invalid email address")
+		return
+	}
+	if status, msg := h.checkAssignableRole(r, req.Role); status != 0 {
+		h.error(w, status, msg)
+		return
+	}
+
+	var exists bool
+	h.db.QueryRow(ctx, "SELECT EXISTS (SELECT 1 FROM users WHERE lower(email) = $1)", req.Email).Scan(&exists)
+	if exists {
+		h.error(w, http.StatusConflict, "User with this email already exists")
+		return
+	}
+
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Token generation failed")
+		return
+	}
+
+	var invitedBy *string
+	if userID := middleware.UserID(ctx); userID != "" {
+		invitedBy = &userID
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	tx.Exec(ctx, "DELETE FROM user_invitations WHERE lower(email) = $1 AND accepted_at IS NULL", req.Email)
+
+	inv := models.UserInvitation{Email: req.Email, Name: req.Name, Role: req.Role, InvitedBy: invitedBy, Token: token}
+	err = tx.QueryRow(ctx, `
+		INSERT INTO user_invitations (email, name, role, token_hash, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NOW() + make_interval(hours => $6))
+		RETURNING id, expires_at, created_at
+	`, req.Email, req.Name, req.Role, hash, invitedBy, h.cfg.InviteTTLHours).Scan(&inv.ID, &inv.ExpiresAt, &inv.CreatedAt)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to create invitation")
+		return
+	}
+	if err := tx.Commit(ctx); err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to create invitation")
+		return
+	}
+
+	h.json(w, http.StatusCreated, inv)
+}
+
+func (h *Handler) DeleteInvitation(w http.ResponseWriter, r *http.Request) {
+	result, err := h.db.Exec(r.Context(), `
+		DELETE FROM user_invitations WHERE id::text = $1 AND accepted_at IS NULL
+	`, chi.URLParam(r, "id"))
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to delete invitation")
+		return
+	}
+	if result.RowsAffected() == 0 {
+		h.error(w, http.StatusNotFound, "Invitation not found")
+		return
+	}
+
+	h.json(w, http.StatusOK, map[string]string{"status": "deleted"})
+}
+
+// GetInvitation - verejný náhľad pozvánky podľa ?token= pre registračný formulár
+func (h *Handler) GetInvitation(w http.ResponseWriter, r *http.Request) {
+	var inv models.UserInvitation
+	err := h.db.QueryRow(r.Context(), `
+		SELECT id, email, name, role, expires_at, created_at
+		FROM user_invitations
+		WHERE token_hash = $1 AND accepted_at IS NULL
+	`, auth.HashToken(r.URL.Query().Get("token"))).Scan(&inv.ID, &inv.Email, &inv.Name, &inv.Role,
+		&inv.ExpiresAt, &inv.CreatedAt)
+	if err != nil {
+		h.error(w, http.StatusNotFound, "Invitation not found")
+		return
+	}
+	if time.Now().After(inv.ExpiresAt) {
+		h.error(w, http.StatusGone, "Invitation has expired")
+		return
+	}
+
+	h.json(w, http.StatusOK, inv)
+}
+
+// AcceptInvitation - {"token", "name", "password"}; založí účet a rovno prihlási
+func (h *Handler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req struct {
+		Token    string `json:"token"`
+		Name     string `json:"name"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := auth.ValidatePassword(req.Password); err != nil {
+		h.error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	hash, err := auth.HashPassword(req.Password)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Password hashing failed")
+		return
+	}
+
+	tx, err := h.db.Begin(ctx)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	defer tx.Rollback(ctx)
+
+	var inv models.UserInvitation
+	err = tx.QueryRow(ctx, `
+		SELECT id, email, name, role, expires_at
+		FROM user_invitations
+		WHERE token_hash = $1 AND accepted_at IS NULL
+		FOR UPDATE
+	`, auth.HashToken(req.Token)).Scan(&inv.ID, &inv.Email, &inv.Name, &inv.Role, &inv.ExpiresAt)
+	if err != nil {
+		h.error(w, http.StatusNotFound, "Invitation not found")
+		return
+	}
+	if time.Now().After(inv.ExpiresAt) {
+		h.error(w, http.StatusGone, "Invitation has expired")
+		return
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" && inv.Name != nil {
+		name = *inv.Name
+	}
+	if name == "" {
+		h.error(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	var user models.User
+	row := tx.QueryRow(ctx, `
+		INSERT INTO users (email, password_hash, name, role, last_login)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING `+userColumns, inv.Email, hash, name, inv.Role)
+	if err := scanUser(row, &user); err != nil {
+		h.error(w, http.StatusConflict, "Email already exists")
+		return
+	}
+
+	tx.Exec(ctx, "UPDATE user_invitations SET accepted_at = NOW() WHERE id = $1", inv.ID)
+	if err := tx.Commit(ctx); err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to accept invitation")
+		return
+	}
+
+	h.startSession(w, r, http.StatusCreated, &user)
+}
+
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+// PROFILE (/me)
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+// GetMe - prihlásený používateľ s oprávneniami
+func (h *Handler) GetMe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var user models.User
+	row := h.db.QueryRow(ctx, "SELECT "+userColumns+" FROM users WHERE id::text = $1", middleware.UserID(ctx))
+	if err := scanUser(row, &user); err != nil {
+		h.error(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	permissions, _ := h.roles.Permissions(ctx, user.Role)
+	h.json(w, http.StatusOK, map[string]interface{}{
+		"user":        user,
+		"permissions": permissions,
+	})
+}
+
+// UpdateMe - {"name", "email", "current_password"}; zmena e-mailu vyžaduje aktuálne heslo
+func (h *Handler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req struct {
+		Name            *string `json:"name"`
+		Email           *string `json:"email"`
+		CurrentPassword string  `json:"current_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	var user models.User
+	err := h.db.QueryRow(ctx, `
+		SELECT id, email, name, password_hash FROM users WHERE id::text = $1
+	`, middleware.UserID(ctx)).Scan(&user.ID, &user.Email, &user.Name, &user.PasswordHash)
+	if err != nil {
+		h.error(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+
+	if req.Email != nil && normalizeEmail(*req.Email) != user.Email {
+		if !auth.CheckPassword(user.PasswordHash, req.CurrentPassword) {
+			h.error(w, http.StatusForbidden, "Current password is incorrect")
+			return
+		}
+		user.Email = normalizeEmail(*req.Email)
+	}
+	if req.Name != nil {
+		user.Name = strings.TrimSpace(*req.Name)
+	}
+	if msg := validateAccount(user.Email, user.Name); msg != "" {
+		h.error(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	row := h.db.QueryRow(ctx, `
+		UPDATE users SET email = $2, name = $3, updated_at = NOW()
+		WHERE id = $1
+		RETURNING `+userColumns, user.ID, user.Email, user.Name)
+	if err := scanUser(row, &user); err != nil {
+		h.error(w, http.StatusConflict, "Email already exists")
+		return
+	}
+
+	h.json(w, http.StatusOK, user)
+}
+
+// ChangePassword - {"current_password", "new_password"}
+func (h *Handler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req struct {
+		CurrentPassword string `json:"current_password"`
+		NewPassword     string `json:"new_password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if err := auth.ValidatePassword(req.NewPassword); err != nil {
+		h.error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID := middleware.UserID(ctx)
+	var current string
+	if err := h.db.QueryRow(ctx, "SELECT password_hash FROM users WHERE id::text = $1", userID).Scan(&current); err != nil {
+		h.error(w, http.StatusUnauthorized, "User not found")
+		return
+	}
+	if !auth.CheckPassword(current, req.CurrentPassword) {
+		h.error(w, http.StatusForbidden, "Current password is incorrect")
+		return
+	}
+
+	hash, err := auth.HashPassword(req.NewPassword)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Password hashing failed")
+		return
+	}
+	h.db.Exec(ctx, "UPDATE users SET password_hash = $2, updated_at = NOW() WHERE id::text = $1", userID, hash)
+
+	// Other devices have to log in with the new password
+	h.sessions.RevokeUser(ctx, userID, middleware.SessionID(ctx), auth.RevokedPasswordChange)
+
+	h.json(w, http.StatusOK, map[string]string{"status": "updated"})
+}
+
+// ListSessions - aktívne relácie prihláseného používateľa
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	current := middleware.SessionID(ctx)
+
+	rows, err := h.db.Query(ctx, `
+		SELECT id, user_agent, ip, created_at, last_used_at, expires_at
+		FROM sessions
+		WHERE user_id::text = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_used_at DESC
+	`, middleware.UserID(ctx))
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Database error")
+		return
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var session models.Session
+		rows.Scan(&session.ID, &session.UserAgent, &session.IP, &session.CreatedAt,
+			&session.LastUsedAt, &session.ExpiresAt)
+		session.Current = session.ID == current
+		sessions = append(sessions, session)
+	}
+
+	h.json(w, http.StatusOK, sessions)
+}
+
+// RevokeSession odhlási jednu z vlastných relácií (napr. stratené zariadenie)
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	revoked, err := h.sessions.Revoke(ctx, middleware.UserID(ctx), chi.URLParam(r, "id"), auth.RevokedLogout)
+	if err != nil {
+		h.error(w, http.StatusInternalServerError, "Failed to revoke session")
+		return
+	}
+	if !revoked {
+		h.error(w, http.StatusNotFound, "Session not found")
+		return
+	}
+
+	h.json(w, http.StatusOK, map[string]string{"status": "revoked"})
+}
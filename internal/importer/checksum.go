@@ -0,0 +1,67 @@
+package importer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"eshopbuilder/internal/models"
+)
+
+// checksumVersion - Verzia algoritmu; pri zmene sa všetky produkty raz aktualizujú,
+// lebo uložené checksumy s inou verziou sa nezhodujú
+const checksumVersion = "v2"
+
+// checksumFields - Všetky polia FeedItem v kanonickom poradí
+var checksumFields = []string{
+	"title", "description", "short_description", "price", "regular_price", "sale_price",
+	"ean", "sku", "mpn", "external_id", "item_group_id", "image_url", "gallery_images",
+	"category", "brand", "manufacturer", "stock_status", "stock_quantity", "attributes",
+	"affiliate_url", "button_text", "delivery_time",
+}
+
+// calculateChecksum - SHA-256 kanonickej serializácie zadaných polí položky
+func (e *ImportEngine) calculateChecksum(item *models.FeedItem, fields []string) string {
+	pairs := make([][2]string, 0, len(fields))
+	for _, field := range fields {
+		pairs = append(pairs, [2]string{field, canonicalValue(item, field)})
+	}
+	data, _ := json.Marshal(pairs)
+	hash := sha256.Sum256(data)
+	return checksumVersion + ":" + hex.EncodeToString(hash[:])
+}
+
+// fieldChecksums vráti skrátený hash každého poľa pre počítadlá zmien
+func fieldChecksums(item *models.FeedItem) map[string]string {
+	sums := make(map[string]string, len(checksumFields))
+	for _, field := range checksumFields {
+		hash := sha256.Sum256([]byte(canonicalValue(item, field)))
+		sums[field] = hex.EncodeToString(hash[:8])
+	}
+	return sums
+}
+
+// countFieldChanges pripočíta polia, ktorých hash sa od minulého importu zmenil
+func (e *ImportEngine) countFieldChanges(previous, current map[string]string) {
+	// Nothing stored yet (first run after the checksum upgrade)
+	if len(previous) == 0 {
+		return
+	}
+	for field, sum := range current {
+		if previous[field] != sum {
+			e.progress.FieldChanges[field]++
+		}
+	}
+}
+
+// canonicalValue - hodnota poľa s usporiadanými zoznamami
+func canonicalValue(item *models.FeedItem, field string) string {
+	if field == "gallery_images" {
+		images := append([]string(nil), item.GalleryImages...)
+		sort.Strings(images)
+		return strings.Join(images, "|")
+	}
+	return itemFieldValue(item, field)
+}
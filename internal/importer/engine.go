@@ -2,8 +2,6 @@ package importer
 
 import (
 	"context"
-	"crypto/md5"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"regexp"
@@ -13,6 +11,8 @@ import (
 	"time"
 
 	"eshopbuilder/internal/models"
+	"eshopbuilder/internal/pricehistory"
+	"eshopbuilder/internal/slug"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -32,6 +32,19 @@ type ImportEngine struct {
 	startTime  time.Time
 
 	categoryCache map[string]string
+	settings      models.FeedSettings
+	filter        *itemFilter
+	validator     *itemValidator
+	seenProducts  map[string]bool
+	seenVariants  map[string]bool
+	groupParents  map[string]string // item_group_id -> parent product ID
+
+	// Global limits, overridden by feed settings
+	MaxBytes           int64
+	MaxItemDropPercent float64
+
+	// Days of price history to keep, 0 = forever
+	PriceHistoryRetentionDays int
 }
 
 // NewImportEngine vytvorí nový engine
@@ -63,6 +76,9 @@ func (e *ImportEngine) Run(ctx context.Context, triggeredBy string) (*models.Imp
 	// Initialize
 	e.historyID = uuid.New().String()
 	e.startTime = time.Now()
+	e.seenProducts = make(map[string]bool)
+	e.seenVariants = make(map[string]bool)
+	e.groupParents = make(map[string]string)
 
 	e.progress = &models.ImportProgress{
 		FeedID:    e.feed.ID,
@@ -70,6 +86,10 @@ func (e *ImportEngine) Run(ctx context.Context, triggeredBy string) (*models.Imp
 		Status:    models.ImportStatusRunning,
 		Message:   "Initializing import...",
 		Logs:      []models.LogEntry{},
+
+		ValidationCounts: make(map[string]int),
+		FieldChanges:     make(map[string]int),
+		FilterCounts:     make(map[string]int),
 	}
 
 	history := &models.ImportHistory{
@@ -87,10 +107,33 @@ func (e *ImportEngine) Run(ctx context.Context, triggeredBy string) (*models.Imp
 	e.log("info", "Import started for feed: "+e.feed.Name)
 	e.updateProgress("Downloading feed...")
 
+	e.settings = e.loadSettings()
+
+	filter, err := newItemFilter(e.settings.Filters)
+	if err != nil {
+		return e.failImport(ctx, history, "Invalid filter rules: "+err.Error())
+	}
+	e.filter = filter
+
+	validator, err := newItemValidator(e.settings.Validation, e.loadMappings())
+	if err != nil {
+		return e.failImport(ctx, history, "Invalid validation rules: "+err.Error())
+	}
+	e.validator = validator
+
+	for _, field := range e.settings.UpdateFields {
+		if !models.IsProductField(field) {
+			return e.failImport(ctx, history, fmt.Sprintf("Invalid update_fields: unknown field %q", field))
+		}
+	}
+
 	// Initialize parser
 	e.parser = NewFeedParser(e.feed.FeedURL, string(e.feed.FeedType))
 	e.parser.XMLItemPath = e.feed.XMLItemPath
 	e.parser.CSVDelimiter = e.feed.CSVDelimiter
+	if maxBytes := e.maxBytes(); maxBytes > 0 {
+		e.parser.MaxBytes = maxBytes
+	}
 
 	// Download feed
 	feedData, err := e.parser.Download()
@@ -103,27 +146,33 @@ func (e *ImportEngine) Run(ctx context.Context, triggeredBy string) (*models.Imp
 
 	// Count total items
 	totalCount := 0
+	countCallback := func(item map[string]interface{}) error {
+		totalCount++
+		return nil
+	}
 	switch e.feed.FeedType {
 	case models.FeedTypeXML:
-		e.parser.ParseXMLFull(feedData, func(item map[string]interface{}) error {
-			totalCount++
-			return nil
-		})
+		err = e.parser.ParseXMLFull(feedData, countCallback)
 	case models.FeedTypeCSV:
-		e.parser.ParseCSVFull(feedData, func(item map[string]interface{}) error {
-			totalCount++
-			return nil
-		})
+		err = e.parser.ParseCSVFull(feedData, countCallback)
 	case models.FeedTypeJSON:
-		e.parser.ParseJSONFull(feedData, func(item map[string]interface{}) error {
-			totalCount++
-			return nil
-		})
+		err = e.parser.ParseJSONFull(feedData, countCallback)
+	}
+	if err != nil {
+		return e.failImport(ctx, history, "Parse error: "+err.Error())
 	}
 
 	e.progress.Total = totalCount
 	history.TotalItems = totalCount
 	e.log("info", fmt.Sprintf("Feed parsed: %d items", totalCount))
+
+	// Compare with previous runs - a big drop usually means a truncated or partial feed
+	if reason := e.checkItemCountDrop(ctx, totalCount); reason != "" {
+		history.Suspicious = true
+		history.SuspiciousReason = &reason
+		e.log("warn", "Suspicious run: "+reason)
+	}
+
 	e.updateProgress(fmt.Sprintf("Processing %d items...", totalCount))
 
 	// Process items
@@ -141,13 +190,15 @@ func (e *ImportEngine) Run(ctx context.Context, triggeredBy string) (*models.Imp
 			return nil
 		}
 
-		// Validate
-		if feedItem.Title == "" {
-			e.progress.Errors++
+		// Filter
+		if allowed, filterName := e.filter.Allow(feedItem, item); !allowed {
+			e.progress.Filtered++
+			e.progress.FilterCounts[filterName]++
 			return nil
 		}
 
-		if feedItem.Price == 0 {
+		// Validate
+		if !e.validateItem(feedItem) {
 			e.progress.Errors++
 			return nil
 		}
@@ -183,15 +234,74 @@ func (e *ImportEngine) Run(ctx context.Context, triggeredBy string) (*models.Imp
 	return e.completeImport(ctx, history)
 }
 
-func (e *ImportEngine) mapItem(raw map[string]interface{}) *models.FeedItem {
-	item := &models.FeedItem{}
+func (e *ImportEngine) loadSettings() models.FeedSettings {
+	var settings models.FeedSettings
+	if e.feed.Settings != nil {
+		data, _ := json.Marshal(e.feed.Settings)
+		json.Unmarshal(data, &settings)
+	}
+	return settings
+}
 
-	// Get field mappings
+func (e *ImportEngine) loadMappings() []models.FieldMapping {
 	var mappings []models.FieldMapping
 	if e.feed.FieldMappings != nil {
 		data, _ := json.Marshal(e.feed.FieldMappings)
 		json.Unmarshal(data, &mappings)
 	}
+	return mappings
+}
+
+func (e *ImportEngine) maxBytes() int64 {
+	if e.settings.MaxBytes > 0 {
+		return e.settings.MaxBytes
+	}
+	return e.MaxBytes
+}
+
+func (e *ImportEngine) maxItemDropPercent() float64 {
+	if e.settings.MaxItemDropPercent > 0 {
+		return e.settings.MaxItemDropPercent
+	}
+	return e.MaxItemDropPercent
+}
+
+// checkItemCountDrop porovná počet položiek s priemerom posledných úspešných importov
+func (e *ImportEngine) checkItemCountDrop(ctx context.Context, totalCount int) string {
+	threshold := e.maxItemDropPercent()
+	if threshold <= 0 {
+		return ""
+	}
+
+	var previous float64
+	var runs int
+	err := e.db.QueryRow(ctx, `
+		SELECT COALESCE(AVG(total_items), 0), COUNT(*) FROM (
+			SELECT total_items FROM import_history
+			WHERE feed_id = $1 AND id <> $2 AND status = 'completed'
+				AND suspicious = false AND total_items > 0
+			ORDER BY started_at DESC
+			LIMIT 5
+		) recent
+	`, e.feed.ID, e.historyID).Scan(&previous, &runs)
+	if err != nil || runs == 0 || previous == 0 {
+		return ""
+	}
+
+	drop := (previous - float64(totalCount)) * 100 / previous
+	if drop < threshold {
+		return ""
+	}
+
+	return fmt.Sprintf("item count dropped %.0f%% (%d items, previous runs averaged %.0f, threshold %.0f%%)",
+		drop, totalCount, previous, threshold)
+}
+
+func (e *ImportEngine) mapItem(raw map[string]interface{}) *models.FeedItem {
+	item := &models.FeedItem{}
+
+	// Get field mappings
+	mappings := e.loadMappings()
 
 	// Apply mappings
 	for _, mapping := range mappings {
@@ -223,6 +333,8 @@ func (e *ImportEngine) mapItem(raw map[string]interface{}) *models.FeedItem {
 			item.SKU = value
 		case "external_id":
 			item.ExternalID = value
+		case "item_group_id":
+			item.ItemGroupID = value
 		case "image_url":
 			item.ImageURL = value
 		case "gallery_images":
@@ -243,6 +355,13 @@ func (e *ImportEngine) mapItem(raw map[string]interface{}) *models.FeedItem {
 			item.ButtonText = value
 		case "delivery_time":
 			item.DeliveryTime = value
+		case "attributes":
+			if value != "" {
+				if item.Attributes == nil {
+					item.Attributes = make(map[string]string)
+				}
+				item.Attributes[mapping.SourceField] = value
+			}
 		}
 	}
 
@@ -252,11 +371,22 @@ func (e *ImportEngine) mapItem(raw map[string]interface{}) *models.FeedItem {
 		item.Description = e.getFieldValue(raw, "DESCRIPTION", "description", "popis")
 		item.Price = e.parsePrice(e.getFieldValue(raw, "PRICE_VAT", "price", "cena"))
 		item.EAN = e.getFieldValue(raw, "EAN", "ean", "ean13", "gtin")
-		item.SKU = e.getFieldValue(raw, "SKU", "sku", "ITEMGROUP_ID", "kod")
+		item.SKU = e.getFieldValue(raw, "SKU", "sku", "kod")
+		item.ItemGroupID = e.getFieldValue(raw, "ITEMGROUP_ID", "item_group_id")
 		item.ImageURL = e.getFieldValue(raw, "IMGURL", "image", "image_url", "img_url")
 		item.CategoryPath = e.getFieldValue(raw, "CATEGORYTEXT", "category", "kategoria")
 		item.Brand = e.getFieldValue(raw, "MANUFACTURER", "brand", "vyrobca")
 		item.AffiliateURL = e.getFieldValue(raw, "URL", "url", "link")
+
+		// Variant attributes (Google size / color)
+		for _, key := range []string{"size", "color", "colour", "material", "pattern"} {
+			if value := e.getFieldValue(raw, key); value != "" {
+				if item.Attributes == nil {
+					item.Attributes = make(map[string]string)
+				}
+				item.Attributes[key] = value
+			}
+		}
 	}
 
 	if item.Title == "" {
@@ -266,6 +396,25 @@ func (e *ImportEngine) mapItem(raw map[string]interface{}) *models.FeedItem {
 	return item
 }
 
+// validateItem zapíše problémy do logu a počítadiel; false = položka zamietnutá
+func (e *ImportEngine) validateItem(item *models.FeedItem) bool {
+	valid := true
+
+	for _, issue := range e.validator.Validate(item) {
+		e.progress.ValidationCounts[issue.Key()]++
+
+		if issue.Rule.Severity == models.ValidationReject {
+			valid = false
+			e.log("error", fmt.Sprintf("Item rejected (%s): %s", item.Title, issue.Message))
+		} else {
+			e.progress.Warnings++
+			e.log("warn", fmt.Sprintf("Item warning (%s): %s", item.Title, issue.Message))
+		}
+	}
+
+	return valid
+}
+
 func (e *ImportEngine) getFieldValue(raw map[string]interface{}, keys ...string) string {
 	for _, key := range keys {
 		if val, ok := raw[key]; ok {
@@ -311,122 +460,171 @@ func (e *ImportEngine) parsePrice(value string) float64 {
 }
 
 func (e *ImportEngine) processItem(ctx context.Context, item *models.FeedItem) error {
-	// Find existing product
-	existingID, checksum := e.findExistingProduct(ctx, item)
-
-	// Calculate new checksum
-	newChecksum := e.calculateChecksum(item)
-
-	// Skip unchanged
-	if existingID != "" && checksum == newChecksum {
+	// Variants are grouped under a parent product
+	if item.ItemGroupID != "" {
+		return e.processVariant(ctx, item)
+	}
+
+	// Find canonical product (possibly created by another feed)
+	existing := e.findExistingProduct(ctx, item)
+	owned := existing.ID != "" && existing.OwnerFeedID == e.feed.ID
+
+	// The offer checksum covers the whole item as this feed sent it, the content
+	// checksum only fields this run may write (unlocked and allowed by the feed)
+	offerChecksum := e.calculateChecksum(item, checksumFields)
+	fields := fieldChecksums(item)
+	writable := e.writableFields(existing.LockedFields)
+	contentChecksum := e.calculateChecksum(item, writable)
+	contentChanged := existing.ID == "" || (owned && existing.Checksum != contentChecksum)
+
+	// Skip unchanged offer
+	var offer storedOffer
+	if existing.ID != "" {
+		offer = e.findOffer(ctx, existing.ID)
+	}
+	if offer.ID != "" && offer.Checksum == offerChecksum && !contentChanged {
+		e.seenProducts[existing.ID] = true
 		e.progress.Skipped++
 		return nil
 	}
+	if offer.ID != "" {
+		e.countFieldChanges(offer.FieldChecksums, fields)
+	}
 
 	// Get or create category
 	var categoryID *string
-	if item.CategoryPath != "" {
+	if item.CategoryPath != "" && contentChanged && (existing.ID == "" || containsField(writable, "category")) {
 		categoryID = e.getOrCreateCategory(ctx, item.CategoryPath)
 	}
 
-	if existingID != "" {
-		// Update
-		if err := e.updateProduct(ctx, existingID, item, categoryID, newChecksum); err != nil {
-			return err
+	productID := existing.ID
+	if existing.ID != "" {
+		// Only the feed that created the product owns its content,
+		// other feeds just contribute an offer
+		if contentChanged {
+			if err := e.updateProduct(ctx, existing.ID, item, categoryID, contentChecksum, writable); err != nil {
+				return err
+			}
 		}
 		e.progress.Updated++
 	} else {
 		// Create
-		if err := e.createProduct(ctx, item, categoryID, newChecksum); err != nil {
+		id, err := e.createProduct(ctx, item, categoryID, contentChecksum)
+		if err != nil {
 			return err
 		}
+		productID = id
 		e.progress.Created++
 	}
+	e.seenProducts[productID] = true
 
-	return nil
-}
-
-func (e *ImportEngine) findExistingProduct(ctx context.Context, item *models.FeedItem) (string, string) {
-	var id, checksum string
-
-	switch e.feed.MatchBy {
-	case models.MatchByEAN:
-		if item.EAN != "" {
-			e.db.QueryRow(ctx, "SELECT id, feed_checksum FROM products WHERE ean = $1 LIMIT 1", item.EAN).Scan(&id, &checksum)
-		}
-	case models.MatchBySKU:
-		if item.SKU != "" {
-			e.db.QueryRow(ctx, "SELECT id, feed_checksum FROM products WHERE sku = $1 LIMIT 1", item.SKU).Scan(&id, &checksum)
-		}
-	case models.MatchByExternalID:
-		if item.ExternalID != "" {
-			e.db.QueryRow(ctx, "SELECT id, feed_checksum FROM products WHERE external_id = $1 LIMIT 1", item.ExternalID).Scan(&id, &checksum)
-		}
-	case models.MatchByTitle:
-		e.db.QueryRow(ctx, "SELECT id, feed_checksum FROM products WHERE title = $1 LIMIT 1", item.Title).Scan(&id, &checksum)
+	if err := e.upsertOffer(ctx, productID, item, offerChecksum, fields); err != nil {
+		return fmt.Errorf("offer: %w", err)
 	}
 
-	return id, checksum
-}
+	if err := RefreshBestOffers(ctx, e.db, []string{productID}); err != nil {
+		return err
+	}
 
-func (e *ImportEngine) calculateChecksum(item *models.FeedItem) string {
-	data := fmt.Sprintf("%s|%s|%s|%.2f|%s|%s",
-		item.Title, item.Description, item.EAN, item.Price, item.ImageURL, item.CategoryPath)
-	hash := md5.Sum([]byte(data))
-	return hex.EncodeToString(hash[:])
+	return pricehistory.Record(ctx, e.db, []string{productID}, pricehistory.SourceImport)
 }
 
-func (e *ImportEngine) createProduct(ctx context.Context, item *models.FeedItem, categoryID *string, checksum string) error {
+func (e *ImportEngine) createProduct(ctx context.Context, item *models.FeedItem, categoryID *string, checksum string) (string, error) {
 	id := uuid.New().String()
-	slug := e.generateSlug(item.Title)
-
 	gallery, _ := json.Marshal(item.GalleryImages)
 	attrs, _ := json.Marshal(item.Attributes)
 
-	_, err := e.db.Exec(ctx, `
-		INSERT INTO products (
-			id, slug, title, description, short_description, price, regular_price, sale_price,
-			ean, sku, external_id, image_url, gallery_images, category_id, category_path,
-			brand, manufacturer, stock_status, stock_quantity, affiliate_url, button_text,
-			delivery_time, attributes, feed_id, feed_checksum, is_active
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15,
-			$16, $17, $18, $19, $20, $21, $22, $23, $24, $25, true
-		)
-	`, id, slug, item.Title, item.Description, item.ShortDescription, item.Price,
-		nullIfZero(item.RegularPrice), nullIfZero(item.SalePrice),
-		nullIfEmpty(item.EAN), nullIfEmpty(item.SKU), nullIfEmpty(item.ExternalID),
-		nullIfEmpty(item.ImageURL), gallery, categoryID, item.CategoryPath,
-		nullIfEmpty(item.Brand), nullIfEmpty(item.Manufacturer),
-		coalesce(item.StockStatus, "instock"), nullIfZero(float64(item.StockQuantity)),
-		nullIfEmpty(item.AffiliateURL), coalesce(item.ButtonText, "Kúpiť"),
-		nullIfEmpty(item.DeliveryTime), attrs, e.feed.ID, checksum)
+	// Another import may take the same slug in the meantime, pick the next one
+	for attempt := 0; ; attempt++ {
+		productSlug, err := slug.Unique(ctx, e.db, "products", item.Title, "")
+		if err != nil {
+			return "", err
+		}
 
-	return err
+		_, err = e.db.Exec(ctx, `
+			INSERT INTO products (
+				id, slug, title, description, short_description, price, regular_price, sale_price,
+				ean, sku, external_id, image_url, gallery_images, category_id, category_path,
+				brand, manufacturer, stock_status, stock_quantity, affiliate_url, button_text,
+				delivery_time, attributes, feed_id, feed_checksum, item_group_id, is_active
+			) VALUES (
+				$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15,
+				$16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, true
+			)
+		`, id, productSlug, item.Title, item.Description, item.ShortDescription, item.Price,
+			nullIfZero(item.RegularPrice), nullIfZero(item.SalePrice),
+			nullIfEmpty(item.EAN), nullIfEmpty(item.SKU), nullIfEmpty(item.ExternalID),
+			nullIfEmpty(item.ImageURL), gallery, categoryID, item.CategoryPath,
+			nullIfEmpty(item.Brand), nullIfEmpty(item.Manufacturer),
+			coalesce(item.StockStatus, "instock"), nullIfZero(float64(item.StockQuantity)),
+			nullIfEmpty(item.AffiliateURL), coalesce(item.ButtonText, "Kúpiť"),
+			nullIfEmpty(item.DeliveryTime), attrs, e.feed.ID, checksum, nullIfEmpty(item.ItemGroupID))
+
+		if err == nil || !slug.IsConflict(err) || attempt == 2 {
+			return id, err
+		}
+	}
 }
 
-func (e *ImportEngine) updateProduct(ctx context.Context, id string, item *models.FeedItem, categoryID *string, checksum string) error {
-	gallery, _ := json.Marshal(item.GalleryImages)
-	attrs, _ := json.Marshal(item.Attributes)
+// updateProduct zapíše len zadané polia (odomknuté a povolené feedom)
+func (e *ImportEngine) updateProduct(ctx context.Context, id string, item *models.FeedItem, categoryID *string, checksum string, fields []string) error {
+	args := []interface{}{id}
+	sets := []string{}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		sets = append(sets, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
 
-	_, err := e.db.Exec(ctx, `
-		UPDATE products SET
-			title = $2, description = $3, short_description = $4, price = $5,
-			regular_price = $6, sale_price = $7, ean = $8, sku = $9, external_id = $10,
-			image_url = $11, gallery_images = $12, category_id = $13, category_path = $14,
-			brand = $15, manufacturer = $16, stock_status = $17, stock_quantity = $18,
-			affiliate_url = $19, button_text = $20, delivery_time = $21, attributes = $22,
-			feed_checksum = $23, updated_at = NOW()
-		WHERE id = $1
-	`, id, item.Title, item.Description, item.ShortDescription, item.Price,
-		nullIfZero(item.RegularPrice), nullIfZero(item.SalePrice),
-		nullIfEmpty(item.EAN), nullIfEmpty(item.SKU), nullIfEmpty(item.ExternalID),
-		nullIfEmpty(item.ImageURL), gallery, categoryID, item.CategoryPath,
-		nullIfEmpty(item.Brand), nullIfEmpty(item.Manufacturer),
-		coalesce(item.StockStatus, "instock"), nullIfZero(float64(item.StockQuantity)),
-		nullIfEmpty(item.AffiliateURL), coalesce(item.ButtonText, "Kúpiť"),
-		nullIfEmpty(item.DeliveryTime), attrs, checksum)
+	for _, field := range fields {
+		switch field {
+		case "title":
+			set("title", item.Title)
+		case "description":
+			set("description", item.Description)
+		case "short_description":
+			set("short_description", item.ShortDescription)
+		case "price":
+			set("price", item.Price)
+		case "regular_price":
+			set("regular_price", nullIfZero(item.RegularPrice))
+		case "sale_price":
+			set("sale_price", nullIfZero(item.SalePrice))
+		case "ean":
+			set("ean", nullIfEmpty(item.EAN))
+		case "sku":
+			set("sku", nullIfEmpty(item.SKU))
+		case "external_id":
+			set("external_id", nullIfEmpty(item.ExternalID))
+		case "image_url":
+			set("image_url", nullIfEmpty(item.ImageURL))
+		case "gallery_images":
+			gallery, _ := json.Marshal(item.GalleryImages)
+			set("gallery_images", gallery)
+		case "category":
+			set("category_id", categoryID)
+			set("category_path", item.CategoryPath)
+		case "brand":
+			set("brand", nullIfEmpty(item.Brand))
+		case "manufacturer":
+			set("manufacturer", nullIfEmpty(item.Manufacturer))
+		case "stock_status":
+			set("stock_status", coalesce(item.StockStatus, "instock"))
+		case "stock_quantity":
+			set("stock_quantity", nullIfZero(float64(item.StockQuantity)))
+		case "attributes":
+			attrs, _ := json.Marshal(item.Attributes)
+			set("attributes", attrs)
+		case "affiliate_url":
+			set("affiliate_url", nullIfEmpty(item.AffiliateURL))
+		case "button_text":
+			set("button_text", coalesce(item.ButtonText, "Kúpiť"))
+		case "delivery_time":
+			set("delivery_time", nullIfEmpty(item.DeliveryTime))
+		}
+	}
+	set("feed_checksum", checksum)
 
+	_, err := e.db.Exec(ctx, "UPDATE products SET "+strings.Join(sets, ", ")+", updated_at = NOW() WHERE id = $1", args...)
 	return err
 }
 
@@ -458,23 +656,22 @@ func (e *ImportEngine) getOrCreateCategory(ctx context.Context, categoryPath str
 			continue
 		}
 
-		slug := e.generateSlug(name)
-
-		// Find existing
+		// Find existing by name under the same parent, slugs may carry a suffix
 		var categoryID string
 		err := e.db.QueryRow(ctx, `
 			SELECT id FROM categories 
-			WHERE slug = $1 AND (parent_id = $2 OR (parent_id IS NULL AND $2 IS NULL))
+			WHERE LOWER(name) = LOWER($1) AND (parent_id = $2 OR (parent_id IS NULL AND $2::uuid IS NULL))
+			ORDER BY created_at
 			LIMIT 1
-		`, slug, parentID).Scan(&categoryID)
+		`, name, parentID).Scan(&categoryID)
 
 		if err != nil {
 			// Create
-			categoryID = uuid.New().String()
-			e.db.Exec(ctx, `
-				INSERT INTO categories (id, name, slug, parent_id, product_count, is_active)
-				VALUES ($1, $2, $3, $4, 0, true)
-			`, categoryID, name, slug, parentID)
+			categoryID, err = e.createCategory(ctx, name, parentID)
+			if err != nil {
+				e.log("error", "Category create failed: "+err.Error())
+				return nil
+			}
 		}
 
 		if categoryID != "" {
@@ -491,29 +688,23 @@ func (e *ImportEngine) getOrCreateCategory(ctx context.Context, categoryPath str
 	return nil
 }
 
-func (e *ImportEngine) generateSlug(text string) string {
-	slug := strings.ToLower(text)
-
-	replacements := map[string]string{
-		"á": "a", "ä": "a", "č": "c", "ď": "d", "é": "e", "í": "i",
-		"ĺ": "l", "ľ": "l", "ň": "n", "ó": "o", "ô": "o", "ŕ": "r",
-		"š": "s", "ť": "t", "ú": "u", "ý": "y", "ž": "z",
-	}
-	for from, to := range replacements {
-		slug = strings.ReplaceAll(slug, from, to)
-	}
+func (e *ImportEngine) createCategory(ctx context.Context, name string, parentID *string) (string, error) {
+	id := uuid.New().String()
+	for attempt := 0; ; attempt++ {
+		categorySlug, err := slug.Unique(ctx, e.db, "categories", name, "")
+		if err != nil {
+			return "", err
+		}
 
-	slug = regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(slug, "-")
-	slug = strings.Trim(slug, "-")
+		_, err = e.db.Exec(ctx, `
+			INSERT INTO categories (id, name, slug, parent_id, product_count, is_active)
+			VALUES ($1, $2, $3, $4, 0, true)
+		`, id, name, categorySlug, parentID)
 
-	if len(slug) > 200 {
-		slug = slug[:200]
+		if err == nil || !slug.IsConflict(err) || attempt == 2 {
+			return id, err
+		}
 	}
-
-	// Add unique suffix to avoid collisions
-	slug = slug + "-" + uuid.New().String()[:8]
-
-	return slug
 }
 
 // Progress and status updates
@@ -578,25 +769,50 @@ func (e *ImportEngine) completeImport(ctx context.Context, history *models.Impor
 	history.Created = e.progress.Created
 	history.Updated = e.progress.Updated
 	history.Skipped = e.progress.Skipped
+	history.Filtered = e.progress.Filtered
 	history.Errors = e.progress.Errors
+	history.Warnings = e.progress.Warnings
+	history.ValidationCounts = e.progress.ValidationCounts
+	history.FieldChanges = e.progress.FieldChanges
+	history.FilterCounts = e.progress.FilterCounts
 	history.Status = models.ImportStatusCompleted
 
 	e.saveHistory(ctx, history)
-	e.updateFeedStatus(ctx, "active", "")
+	// Variants missing from the feed are deactivated first so parent prices ignore them
+	e.reconcileMissing(ctx, history)
+	e.refreshVariantParents(ctx)
+	if history.Suspicious {
+		e.updateFeedStatus(ctx, "active", "Suspicious import: "+*history.SuspiciousReason)
+	} else {
+		e.updateFeedStatus(ctx, "active", "")
+	}
 	e.updateCategoryCounts(ctx)
+	e.pruneHistory(ctx)
 
 	e.progress.Status = models.ImportStatusCompleted
 	e.progress.Message = "Import completed"
 	e.updateProgress("Import completed")
 
 	e.log("info", fmt.Sprintf(
-		"Import completed: %d created, %d updated, %d skipped, %d errors. Duration: %ds",
-		e.progress.Created, e.progress.Updated, e.progress.Skipped, e.progress.Errors, duration,
+		"Import completed: %d created, %d updated, %d skipped, %d filtered, %d errors. Duration: %ds",
+		e.progress.Created, e.progress.Updated, e.progress.Skipped, e.progress.Filtered, e.progress.Errors, duration,
 	))
 
 	return history, nil
 }
 
+// pruneHistory zmaže starú históriu cien podľa nastavenej retencie
+func (e *ImportEngine) pruneHistory(ctx context.Context) {
+	deleted, err := pricehistory.Prune(ctx, e.db, e.PriceHistoryRetentionDays)
+	if err != nil {
+		e.log("error", "Price history cleanup failed: "+err.Error())
+		return
+	}
+	if deleted > 0 {
+		e.log("info", fmt.Sprintf("Removed %d old price history records", deleted))
+	}
+}
+
 func (e *ImportEngine) failImport(ctx context.Context, history *models.ImportHistory, errorMsg string) (*models.ImportHistory, error) {
 	finishedAt := time.Now()
 	history.FinishedAt = &finishedAt
@@ -616,6 +832,80 @@ func (e *ImportEngine) failImport(ctx context.Context, history *models.ImportHis
 	return history, fmt.Errorf(errorMsg)
 }
 
+// reconcileMissing deaktivuje ponuky (a vlastné produkty) feedu, ktoré v tomto behu chýbali
+func (e *ImportEngine) reconcileMissing(ctx context.Context, history *models.ImportHistory) {
+	if !e.settings.DeactivateMissing {
+		return
+	}
+
+	// Never trust a partial run to decide what disappeared from the feed
+	if history.Suspicious || e.shouldStop || len(e.seenProducts) == 0 {
+		e.log("warn", "Missing-product reconciliation skipped")
+		return
+	}
+
+	seen := make([]string, 0, len(e.seenProducts))
+	for id := range e.seenProducts {
+		seen = append(seen, id)
+	}
+
+	rows, err := e.db.Query(ctx, `
+		UPDATE product_offers SET is_active = false, updated_at = NOW()
+		WHERE feed_id = $1 AND is_active = true AND NOT (product_id::text = ANY($2))
+		RETURNING product_id
+	`, e.feed.ID, seen)
+	if err != nil {
+		e.log("error", "Missing-product reconciliation failed: "+err.Error())
+		return
+	}
+	affected := []string{}
+	for rows.Next() {
+		var id string
+		rows.Scan(&id)
+		affected = append(affected, id)
+	}
+	rows.Close()
+
+	if err := RefreshBestOffers(ctx, e.db, affected); err != nil {
+		e.log("error", "Best offer refresh failed: "+err.Error())
+	}
+	if err := pricehistory.Record(ctx, e.db, affected, pricehistory.SourceImport); err != nil {
+		e.log("error", "Price history failed: "+err.Error())
+	}
+
+	variants := make([]string, 0, len(e.seenVariants))
+	for id := range e.seenVariants {
+		variants = append(variants, id)
+	}
+	variantTag, err := e.db.Exec(ctx, `
+		UPDATE product_variants v SET is_active = false, updated_at = NOW()
+		FROM products p
+		WHERE v.product_id = p.id AND p.feed_id = $1 AND v.is_active = true
+			AND NOT (v.id::text = ANY($2))
+	`, e.feed.ID, variants)
+	if err != nil {
+		e.log("error", "Missing-variant reconciliation failed: "+err.Error())
+		return
+	}
+
+	tag, err := e.db.Exec(ctx, `
+		UPDATE products SET is_active = false, updated_at = NOW()
+		WHERE feed_id = $1 AND is_active = true AND NOT (id::text = ANY($2))
+			AND NOT EXISTS (
+				SELECT 1 FROM product_offers o WHERE o.product_id = products.id AND o.is_active = true
+			)
+	`, e.feed.ID, seen)
+	if err != nil {
+		e.log("error", "Missing-product reconciliation failed: "+err.Error())
+		return
+	}
+
+	if len(affected) > 0 || variantTag.RowsAffected() > 0 || tag.RowsAffected() > 0 {
+		e.log("info", fmt.Sprintf("Deactivated %d offers, %d variants and %d products missing from feed",
+			len(affected), variantTag.RowsAffected(), tag.RowsAffected()))
+	}
+}
+
 func (e *ImportEngine) Stop() {
 	e.mutex.Lock()
 	e.shouldStop = true
@@ -630,22 +920,31 @@ func (e *ImportEngine) GetProgress() *models.ImportProgress {
 // Database helpers
 
 func (e *ImportEngine) saveHistory(ctx context.Context, history *models.ImportHistory) error {
+	validationCounts, _ := json.Marshal(history.ValidationCounts)
+	fieldChanges, _ := json.Marshal(history.FieldChanges)
+	filterCounts, _ := json.Marshal(history.FilterCounts)
+
 	_, err := e.db.Exec(ctx, `
 		INSERT INTO import_history (
 			id, feed_id, started_at, finished_at, duration,
 			total_items, processed, created, updated, skipped, errors,
-			status, error_message, triggered_by
+			status, error_message, triggered_by, suspicious, suspicious_reason, filtered,
+			warnings, validation_counts, field_changes, filter_counts
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21
 		)
 		ON CONFLICT (id) DO UPDATE SET
 			finished_at = $4, duration = $5,
 			total_items = $6, processed = $7, created = $8, updated = $9,
-			skipped = $10, errors = $11, status = $12, error_message = $13
+			skipped = $10, errors = $11, status = $12, error_message = $13,
+			suspicious = $15, suspicious_reason = $16, filtered = $17,
+			warnings = $18, validation_counts = $19, field_changes = $20, filter_counts = $21
 	`,
 		history.ID, history.FeedID, history.StartedAt, history.FinishedAt, history.Duration,
 		history.TotalItems, history.Processed, history.Created, history.Updated,
 		history.Skipped, history.Errors, history.Status, history.ErrorMessage, history.TriggeredBy,
+		history.Suspicious, history.SuspiciousReason, history.Filtered,
+		history.Warnings, validationCounts, fieldChanges, filterCounts,
 	)
 	return err
 }
@@ -656,17 +955,40 @@ func (e *ImportEngine) updateFeedStatus(ctx context.Context, status, errorMsg st
 			status = $2,
 			last_run = NOW(),
 			last_error = NULLIF($3, ''),
-			total_products = (SELECT COUNT(*) FROM products WHERE feed_id = $1)
+			total_products = (
+				SELECT COUNT(*) FROM products p
+				WHERE p.feed_id = $1 OR EXISTS (
+					SELECT 1 FROM product_offers o WHERE o.product_id = p.id AND o.feed_id = $1 AND o.is_active = true
+				)
+			)
 		WHERE id = $1
 	`, e.feed.ID, status, errorMsg)
 }
 
 func (e *ImportEngine) updateCategoryCounts(ctx context.Context) {
-	e.db.Exec(ctx, `
-		UPDATE categories c SET product_count = (
-			SELECT COUNT(*) FROM products p WHERE p.category_id = c.id AND p.is_active = true
+	if err := UpdateCategoryCounts(ctx, e.db); err != nil {
+		e.log("error", "Category counts failed: "+err.Error())
+	}
+}
+
+// UpdateCategoryCounts prepočíta počty aktívnych produktov kategórií vrátane podkategórií
+func UpdateCategoryCounts(ctx context.Context, db *pgxpool.Pool) error {
+	_, err := db.Exec(ctx, `
+		WITH RECURSIVE tree AS (
+			SELECT id AS root_id, id FROM categories
+			UNION
+			SELECT t.root_id, c.id FROM categories c JOIN tree t ON c.parent_id = t.id
+		), counts AS (
+			SELECT t.root_id, COUNT(p.id) AS product_count
+			FROM tree t
+			LEFT JOIN products p ON p.category_id = t.id AND p.is_active = true
+			GROUP BY t.root_id
 		)
+		UPDATE categories c SET product_count = counts.product_count
+		FROM counts
+		WHERE counts.root_id = c.id AND c.product_count IS DISTINCT FROM counts.product_count
 	`)
+	return err
 }
 
 // Helper functions
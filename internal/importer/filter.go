@@ -0,0 +1,301 @@
+package importer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"eshopbuilder/internal/models"
+)
+
+// itemFilter - Skompilované filtre feedu
+type itemFilter struct {
+	filters []models.FeedFilter
+	regexes map[string]*regexp.Regexp
+}
+
+var filterOperators = map[string]bool{
+	"equals": true, "not_equals": true, "contains": true, "not_contains": true,
+	"starts_with": true, "regex": true, "gt": true, "gte": true, "lt": true, "lte": true,
+	"in": true, "not_in": true, "empty": true, "not_empty": true,
+}
+
+// newItemFilter overí pravidlá a predkompiluje regexy
+func newItemFilter(filters []models.FeedFilter) (*itemFilter, error) {
+	f := &itemFilter{
+		filters: filters,
+		regexes: make(map[string]*regexp.Regexp),
+	}
+
+	for _, filter := range filters {
+		if filter.Action != "include" && filter.Action != "exclude" {
+			return nil, fmt.Errorf("filter %q: unknown action %q", filter.Name, filter.Action)
+		}
+		if err := f.compileGroup(filter.FilterGroup); err != nil {
+			return nil, fmt.Errorf("filter %q: %w", filter.Name, err)
+		}
+	}
+
+	return f, nil
+}
+
+func (f *itemFilter) compileGroup(group models.FilterGroup) error {
+	if group.Logic != "" && group.Logic != "and" && group.Logic != "or" {
+		return fmt.Errorf("unknown logic %q", group.Logic)
+	}
+
+	for _, rule := range group.Rules {
+		if rule.Field == "" {
+			return fmt.Errorf("rule without field")
+		}
+		if !validFilterField(rule.Field) {
+			return fmt.Errorf("unknown field %q (use a target field, attr:<name> or source:<name>)", rule.Field)
+		}
+		if !filterOperators[rule.Operator] {
+			return fmt.Errorf("unknown operator %q", rule.Operator)
+		}
+		if rule.Operator == "regex" {
+			pattern := rule.Value
+			if !rule.CaseSensitive {
+				pattern = "(?i)" + pattern
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("invalid regex %q: %w", rule.Value, err)
+			}
+			f.regexes[pattern] = re
+		}
+	}
+
+	for _, sub := range group.Groups {
+		if err := f.compileGroup(sub); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Allow vráti false a názov filtra, ak má byť položka preskočená
+func (f *itemFilter) Allow(item *models.FeedItem, raw map[string]interface{}) (bool, string) {
+	for _, filter := range f.filters {
+		matched := f.matchGroup(filter.FilterGroup, item, raw)
+
+		if filter.Action == "include" && !matched {
+			return false, filter.Name
+		}
+		if filter.Action == "exclude" && matched {
+			return false, filter.Name
+		}
+	}
+	return true, ""
+}
+
+func (f *itemFilter) matchGroup(group models.FilterGroup, item *models.FeedItem, raw map[string]interface{}) bool {
+	results := make([]bool, 0, len(group.Rules)+len(group.Groups))
+	for _, rule := range group.Rules {
+		results = append(results, f.matchRule(rule, item, raw))
+	}
+	for _, sub := range group.Groups {
+		results = append(results, f.matchGroup(sub, item, raw))
+	}
+
+	if len(results) == 0 {
+		return true
+	}
+
+	if group.Logic == "or" {
+		for _, r := range results {
+			if r {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, r := range results {
+		if !r {
+			return false
+		}
+	}
+	return true
+}
+
+func (f *itemFilter) matchRule(rule models.FilterRule, item *models.FeedItem, raw map[string]interface{}) bool {
+	value := filterFieldValue(item, raw, rule.Field)
+
+	switch rule.Operator {
+	case "empty":
+		return strings.TrimSpace(value) == ""
+	case "not_empty":
+		return strings.TrimSpace(value) != ""
+	case "gt", "gte", "lt", "lte":
+		return compareNumbers(value, rule.Value, rule.Operator)
+	case "regex":
+		pattern := rule.Value
+		if !rule.CaseSensitive {
+			pattern = "(?i)" + pattern
+		}
+		return f.regexes[pattern].MatchString(value)
+	}
+
+	expected := rule.Value
+	values := rule.Values
+	if !rule.CaseSensitive {
+		value = strings.ToLower(value)
+		expected = strings.ToLower(expected)
+		lowered := make([]string, len(values))
+		for i, v := range values {
+			lowered[i] = strings.ToLower(v)
+		}
+		values = lowered
+	}
+
+	switch rule.Operator {
+	case "equals":
+		return value == expected
+	case "not_equals":
+		return value != expected
+	case "contains":
+		return strings.Contains(value, expected)
+	case "not_contains":
+		return !strings.Contains(value, expected)
+	case "starts_with":
+		return strings.HasPrefix(value, expected)
+	case "in", "not_in":
+		found := false
+		for _, v := range values {
+			if value == v {
+				found = true
+				break
+			}
+		}
+		return found == (rule.Operator == "in")
+	}
+
+	return false
+}
+
+// compareNumbers - nečíselná hodnota na ktorejkoľvek strane sa nezhoduje
+func compareNumbers(value, expected, operator string) bool {
+	a, err := parseNumber(value)
+	if err != nil {
+		return false
+	}
+	b, err := parseNumber(expected)
+	if err != nil {
+		return false
+	}
+
+	switch operator {
+	case "gt":
+		return a > b
+	case "gte":
+		return a >= b
+	case "lt":
+		return a < b
+	case "lte":
+		return a <= b
+	}
+	return false
+}
+
+// parseNumber - číslo s desatinnou bodkou alebo čiarkou
+func parseNumber(value string) (float64, error) {
+	return strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(value), ",", "."), 64)
+}
+
+// validFilterField - cieľové pole alebo attr:/source: s názvom; preklep by inak
+// vrátil prázdnu hodnotu a include pravidlo by zahodilo celý feed
+func validFilterField(field string) bool {
+	for _, prefix := range []string{"attr:", "source:"} {
+		if name, ok := strings.CutPrefix(field, prefix); ok {
+			return strings.TrimSpace(name) != ""
+		}
+	}
+	for _, known := range checksumFields {
+		if field == known {
+			return true
+		}
+	}
+	return false
+}
+
+// filterFieldValue vráti hodnotu cieľového poľa, atribútu (attr:) alebo poľa z feedu (source:)
+func filterFieldValue(item *models.FeedItem, raw map[string]interface{}, field string) string {
+	if name, ok := strings.CutPrefix(field, "source:"); ok {
+		if val, ok := raw[name]; ok {
+			return fmt.Sprintf("%v", val)
+		}
+		return ""
+	}
+	if name, ok := strings.CutPrefix(field, "attr:"); ok {
+		return item.Attributes[name]
+	}
+	return itemFieldValue(item, field)
+}
+
+// itemFieldValue vráti hodnotu cieľového poľa položky ako text
+func itemFieldValue(item *models.FeedItem, field string) string {
+	switch field {
+	case "title":
+		return item.Title
+	case "description":
+		return item.Description
+	case "short_description":
+		return item.ShortDescription
+	case "price":
+		return formatNumber(item.Price)
+	case "regular_price":
+		return formatNumber(item.RegularPrice)
+	case "sale_price":
+		return formatNumber(item.SalePrice)
+	case "ean":
+		return item.EAN
+	case "sku":
+		return item.SKU
+	case "mpn":
+		return item.MPN
+	case "external_id":
+		return item.ExternalID
+	case "item_group_id":
+		return item.ItemGroupID
+	case "image_url":
+		return item.ImageURL
+	case "gallery_images":
+		return strings.Join(item.GalleryImages, "|")
+	case "category":
+		return item.CategoryPath
+	case "brand":
+		return item.Brand
+	case "manufacturer":
+		return item.Manufacturer
+	case "stock_status":
+		return item.StockStatus
+	case "stock_quantity":
+		return strconv.Itoa(item.StockQuantity)
+	case "affiliate_url":
+		return item.AffiliateURL
+	case "button_text":
+		return item.ButtonText
+	case "delivery_time":
+		return item.DeliveryTime
+	case "attributes":
+		pairs := make([]string, 0, len(item.Attributes))
+		for name, value := range item.Attributes {
+			pairs = append(pairs, name+"="+value)
+		}
+		sort.Strings(pairs)
+		return strings.Join(pairs, "|")
+	}
+	return ""
+}
+
+func formatNumber(f float64) string {
+	if f == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
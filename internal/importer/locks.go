@@ -0,0 +1,29 @@
+package importer
+
+import "eshopbuilder/internal/models"
+
+// writableFields vráti polia produktu, ktoré smie import prepísať:
+// povolené nastavením feedu update_fields a nezamknuté na produkte
+func (e *ImportEngine) writableFields(locked []string) []string {
+	allowed := models.ProductFields
+	if len(e.settings.UpdateFields) > 0 {
+		allowed = e.settings.UpdateFields
+	}
+
+	fields := make([]string, 0, len(allowed))
+	for _, field := range models.ProductFields {
+		if containsField(allowed, field) && !containsField(locked, field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,154 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+
+	"eshopbuilder/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// existingProduct - Kanonický produkt nájdený pre položku feedu
+type existingProduct struct {
+	ID           string
+	OwnerFeedID  string
+	Checksum     string
+	LockedFields []string
+}
+
+// findExistingProduct nájde kanonický produkt položky a feed, ktorý ho vlastní.
+// EAN a názov sa párujú naprieč feedmi, SKU a external ID len v rámci ponúk tohto feedu.
+func (e *ImportEngine) findExistingProduct(ctx context.Context, item *models.FeedItem) existingProduct {
+	var p existingProduct
+	const columns = `p.id, COALESCE(p.feed_id::text, ''), COALESCE(p.feed_checksum, ''), COALESCE(p.locked_fields, '[]'::jsonb)`
+
+	switch e.feed.MatchBy {
+	case models.MatchByEAN:
+		if item.EAN != "" {
+			e.db.QueryRow(ctx, `
+				SELECT `+columns+` FROM products p
+				WHERE p.ean = $1 AND p.item_group_id IS NULL
+				ORDER BY (p.feed_id = $2) DESC NULLS LAST, p.created_at
+				LIMIT 1
+			`, item.EAN, e.feed.ID).Scan(&p.ID, &p.OwnerFeedID, &p.Checksum, &p.LockedFields)
+		}
+	case models.MatchBySKU:
+		if item.SKU != "" {
+			e.db.QueryRow(ctx, `
+				SELECT `+columns+` FROM product_offers o
+				JOIN products p ON p.id = o.product_id
+				WHERE o.feed_id = $1 AND o.sku = $2
+				LIMIT 1
+			`, e.feed.ID, item.SKU).Scan(&p.ID, &p.OwnerFeedID, &p.Checksum, &p.LockedFields)
+		}
+	case models.MatchByExternalID:
+		if item.ExternalID != "" {
+			e.db.QueryRow(ctx, `
+				SELECT `+columns+` FROM product_offers o
+				JOIN products p ON p.id = o.product_id
+				WHERE o.feed_id = $1 AND o.external_id = $2
+				LIMIT 1
+			`, e.feed.ID, item.ExternalID).Scan(&p.ID, &p.OwnerFeedID, &p.Checksum, &p.LockedFields)
+		}
+	case models.MatchByTitle:
+		e.db.QueryRow(ctx, `
+			SELECT `+columns+` FROM products p
+			WHERE p.title = $1 AND p.item_group_id IS NULL
+			ORDER BY (p.feed_id = $2) DESC NULLS LAST, p.created_at
+			LIMIT 1
+		`, item.Title, e.feed.ID).Scan(&p.ID, &p.OwnerFeedID, &p.Checksum, &p.LockedFields)
+	}
+
+	return p
+}
+
+// storedOffer - Ponuka tohto feedu uložená pri minulom importe
+type storedOffer struct {
+	ID             string
+	Checksum       string
+	FieldChecksums map[string]string
+}
+
+// findOffer vráti ponuku tohto feedu pre produkt a jej checksumy
+func (e *ImportEngine) findOffer(ctx context.Context, productID string) storedOffer {
+	var o storedOffer
+	e.db.QueryRow(ctx, `
+		SELECT id, COALESCE(feed_checksum, ''), COALESCE(field_checksums, '{}'::jsonb) FROM product_offers
+		WHERE product_id = $1 AND feed_id = $2
+	`, productID, e.feed.ID).Scan(&o.ID, &o.Checksum, &o.FieldChecksums)
+	return o
+}
+
+func (e *ImportEngine) upsertOffer(ctx context.Context, productID string, item *models.FeedItem, checksum string, fields map[string]string) error {
+	fieldSums, _ := json.Marshal(fields)
+	_, err := e.db.Exec(ctx, `
+		INSERT INTO product_offers (
+			product_id, feed_id, price, regular_price, sale_price, stock_status, stock_quantity,
+			delivery_time, affiliate_url, button_text, ean, sku, external_id, feed_checksum,
+			field_checksums, is_active
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, true)
+		ON CONFLICT (product_id, feed_id) DO UPDATE SET
+			price = $3, regular_price = $4, sale_price = $5, stock_status = $6, stock_quantity = $7,
+			delivery_time = $8, affiliate_url = $9, button_text = $10, ean = $11, sku = $12,
+			external_id = $13, feed_checksum = $14, field_checksums = $15, is_active = true,
+			updated_at = NOW()
+	`, productID, e.feed.ID, item.Price,
+		nullIfZero(item.RegularPrice), nullIfZero(item.SalePrice),
+		coalesce(item.StockStatus, "instock"), nullIfZero(float64(item.StockQuantity)),
+		nullIfEmpty(item.DeliveryTime), nullIfEmpty(item.AffiliateURL), coalesce(item.ButtonText, "Kúpiť"),
+		nullIfEmpty(item.EAN), nullIfEmpty(item.SKU), nullIfEmpty(item.ExternalID), checksum, fieldSums)
+	return err
+}
+
+// RefreshBestOffers skopíruje najlepšiu ponuku (skladom, najnižšia cena) do produktov,
+// zamknuté polia produktu ponechá
+func RefreshBestOffers(ctx context.Context, db *pgxpool.Pool, productIDs []string) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	_, err := db.Exec(ctx, `
+		WITH best AS (
+			SELECT DISTINCT ON (product_id)
+				id, product_id, price, regular_price, sale_price, stock_status, stock_quantity,
+				delivery_time, affiliate_url, button_text
+			FROM product_offers
+			WHERE is_active = true AND product_id::text = ANY($1)
+			ORDER BY product_id, (stock_status = 'instock') DESC, COALESCE(sale_price, price), updated_at DESC
+		), counts AS (
+			SELECT product_id, COUNT(*) AS offer_count
+			FROM product_offers
+			WHERE is_active = true AND product_id::text = ANY($1)
+			GROUP BY product_id
+		)
+		UPDATE products p SET
+			best_offer_id = best.id,
+			offer_count = counts.offer_count,
+			price = CASE WHEN p.locked_fields ? 'price' THEN p.price ELSE best.price END,
+			regular_price = CASE WHEN p.locked_fields ? 'regular_price' THEN p.regular_price ELSE best.regular_price END,
+			sale_price = CASE WHEN p.locked_fields ? 'sale_price' THEN p.sale_price ELSE best.sale_price END,
+			stock_status = CASE WHEN p.locked_fields ? 'stock_status' THEN p.stock_status ELSE best.stock_status END,
+			stock_quantity = CASE WHEN p.locked_fields ? 'stock_quantity' THEN p.stock_quantity ELSE best.stock_quantity END,
+			delivery_time = CASE WHEN p.locked_fields ? 'delivery_time' THEN p.delivery_time ELSE best.delivery_time END,
+			affiliate_url = CASE WHEN p.locked_fields ? 'affiliate_url' THEN p.affiliate_url ELSE best.affiliate_url END,
+			button_text = CASE WHEN p.locked_fields ? 'button_text' THEN p.button_text ELSE best.button_text END,
+			updated_at = NOW()
+		FROM best JOIN counts ON counts.product_id = best.product_id
+		WHERE p.id = best.product_id
+	`, productIDs)
+	if err != nil {
+		return err
+	}
+
+	// Products that lost their last offer
+	_, err = db.Exec(ctx, `
+		UPDATE products p SET
+			best_offer_id = NULL, offer_count = 0,
+			stock_status = CASE WHEN p.locked_fields ? 'stock_status' THEN p.stock_status ELSE 'outofstock' END,
+			updated_at = NOW()
+		WHERE p.id::text = ANY($1) AND p.offer_count > 0
+			AND NOT EXISTS (SELECT 1 FROM product_offers o WHERE o.product_id = p.id AND o.is_active = true)
+	`, productIDs)
+	return err
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -16,6 +17,9 @@ import (
 	"golang.org/x/net/html/charset"
 )
 
+// ErrFeedTooLarge - Feed je väčší ako povolený limit
+var ErrFeedTooLarge = errors.New("feed exceeds size limit")
+
 // FeedParser - Parser pre XML, CSV a JSON feedy
 type FeedParser struct {
 	URL          string
@@ -84,6 +88,12 @@ func (p *FeedParser) Download() ([]byte, error) {
 		return nil, fmt.Errorf("HTTP error: %d", resp.StatusCode)
 	}
 
+	// Fail fast when the server announces the size (uncompressed responses only)
+	if p.MaxBytes > 0 && resp.ContentLength > p.MaxBytes && resp.Header.Get("Content-Encoding") != "gzip" {
+		return nil, fmt.Errorf("%w: %d MB announced, limit %d MB",
+			ErrFeedTooLarge, resp.ContentLength/1024/1024, p.MaxBytes/1024/1024)
+	}
+
 	var reader io.Reader = resp.Body
 
 	if resp.Header.Get("Content-Encoding") == "gzip" {
@@ -95,11 +105,24 @@ func (p *FeedParser) Download() ([]byte, error) {
 		reader = gzReader
 	}
 
-	data, err := io.ReadAll(io.LimitReader(reader, p.MaxBytes))
+	if p.MaxBytes <= 0 {
+		data, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("read error: %w", err)
+		}
+		return data, nil
+	}
+
+	// Read one byte over the limit so an oversized feed is detected instead of truncated
+	data, err := io.ReadAll(io.LimitReader(reader, p.MaxBytes+1))
 	if err != nil {
 		return nil, fmt.Errorf("read error: %w", err)
 	}
 
+	if int64(len(data)) > p.MaxBytes {
+		return nil, fmt.Errorf("%w: more than %d MB downloaded", ErrFeedTooLarge, p.MaxBytes/1024/1024)
+	}
+
 	return data, nil
 }
 
@@ -281,6 +304,11 @@ func (p *FeedParser) ParseXMLFull(data []byte, callback func(item map[string]int
 			break
 		}
 		if err != nil {
+			// Feed ended in the middle of an element - never import a partial feed
+			var syntaxErr *xml.SyntaxError
+			if errors.As(err, &syntaxErr) && strings.Contains(syntaxErr.Msg, "unexpected EOF") {
+				return fmt.Errorf("truncated XML feed (line %d): %s", syntaxErr.Line, syntaxErr.Msg)
+			}
 			continue
 		}
 
@@ -586,7 +614,8 @@ func (p *FeedParser) AutoDetectMappings(fields []string) []AutoMapping {
 
 	patterns := map[string][][]string{
 		"ean":           {{"^ean$", "^ean13$", "^gtin$", "^barcode$", "^ITEM_ID$"}},
-		"sku":           {{"^sku$", "^productno$", "^kod$", "^item_id$", "^ITEMGROUP_ID$"}},
+		"sku":           {{"^sku$", "^productno$", "^kod$", "^item_id$"}},
+		"item_group_id": {{"^itemgroup_id$", "^item_group_id$"}},
 		"external_id":   {{"^id$", "^external_id$", "^ext_id$"}},
 		"title":         {{"^productname$", "^product$", "^title$", "^name$", "^nazov$", "^PRODUCTNAME$"}},
 		"description":   {{"^description$", "^popis$", "^desc$", "^DESCRIPTION$"}},
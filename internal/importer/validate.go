@@ -0,0 +1,253 @@
+package importer
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"eshopbuilder/internal/models"
+)
+
+// columnMaxLengths - Veľkosti VARCHAR stĺpcov tabuľky products
+var columnMaxLengths = map[string]int{
+	"title":         500,
+	"ean":           50,
+	"sku":           100,
+	"mpn":           100,
+	"external_id":   255,
+	"brand":         255,
+	"manufacturer":  255,
+	"stock_status":  50,
+	"button_text":   100,
+	"delivery_time": 100,
+}
+
+// ValidationIssue - Výsledok jedného neúspešného pravidla
+type ValidationIssue struct {
+	Rule    models.ValidationRule
+	Message string
+}
+
+// Key vráti kľúč pre počítadlá v import_history
+func (i ValidationIssue) Key() string {
+	return i.Rule.Rule + ":" + i.Rule.Field
+}
+
+// itemValidator - Validácia položiek pred uložením
+type itemValidator struct {
+	rules []models.ValidationRule
+}
+
+// newItemValidator zostaví pravidlá: povinné polia, veľkosti stĺpcov a pravidlá feedu
+func newItemValidator(feedRules []models.ValidationRule, mappings []models.FieldMapping) (*itemValidator, error) {
+	rules := []models.ValidationRule{}
+	index := make(map[string]int)
+
+	add := func(rule models.ValidationRule) {
+		key := rule.Rule + ":" + rule.Field
+		if i, ok := index[key]; ok {
+			rules[i] = rule
+			return
+		}
+		index[key] = len(rules)
+		rules = append(rules, rule)
+	}
+
+	// Defaults
+	for _, field := range models.TargetFields {
+		if field.Required {
+			add(models.ValidationRule{Rule: "required", Field: field.Key, Severity: models.ValidationReject})
+		}
+	}
+	for _, mapping := range mappings {
+		if mapping.IsRequired {
+			add(models.ValidationRule{Rule: "required", Field: mapping.TargetField, Severity: models.ValidationReject})
+		}
+	}
+	columns := make([]string, 0, len(columnMaxLengths))
+	for field := range columnMaxLengths {
+		columns = append(columns, field)
+	}
+	sort.Strings(columns)
+	for _, field := range columns {
+		add(models.ValidationRule{Rule: "max_length", Field: field, Severity: models.ValidationWarn, MaxLength: columnMaxLengths[field]})
+	}
+
+	// Feed rules override defaults with the same rule and field
+	for _, rule := range feedRules {
+		if rule.Severity == "" {
+			rule.Severity = models.ValidationReject
+		}
+		if rule.Severity != models.ValidationWarn && rule.Severity != models.ValidationReject {
+			return nil, fmt.Errorf("rule %s:%s: unknown severity %q", rule.Rule, rule.Field, rule.Severity)
+		}
+
+		switch rule.Rule {
+		case "required", "url":
+		case "ean_checksum":
+			if rule.Field == "" {
+				rule.Field = "ean"
+			}
+		case "max_length":
+			if rule.MaxLength <= 0 {
+				return nil, fmt.Errorf("rule max_length:%s: max_length must be positive", rule.Field)
+			}
+			// Never allow more than the column holds
+			if limit, ok := columnMaxLengths[rule.Field]; ok && rule.MaxLength > limit {
+				rule.MaxLength = limit
+			}
+		case "price_range":
+			if rule.Min == nil && rule.Max == nil {
+				return nil, fmt.Errorf("rule price_range:%s: min or max required", rule.Field)
+			}
+		default:
+			return nil, fmt.Errorf("unknown validation rule %q", rule.Rule)
+		}
+
+		if rule.Field == "" {
+			return nil, fmt.Errorf("rule %s: field required", rule.Rule)
+		}
+		add(rule)
+	}
+
+	return &itemValidator{rules: rules}, nil
+}
+
+// Validate overí položku; pri varovaní max_length hodnotu skráti
+func (v *itemValidator) Validate(item *models.FeedItem) []ValidationIssue {
+	issues := []ValidationIssue{}
+
+	for _, rule := range v.rules {
+		if msg := v.check(rule, item); msg != "" {
+			issues = append(issues, ValidationIssue{Rule: rule, Message: msg})
+			if rule.Rule == "max_length" && rule.Severity == models.ValidationWarn {
+				truncateField(item, rule.Field, rule.MaxLength)
+			}
+		}
+	}
+
+	return issues
+}
+
+func (v *itemValidator) check(rule models.ValidationRule, item *models.FeedItem) string {
+	value := itemFieldValue(item, rule.Field)
+
+	switch rule.Rule {
+	case "required":
+		if strings.TrimSpace(value) == "" {
+			return rule.Field + " is required"
+		}
+	case "ean_checksum":
+		if value != "" && !validGTIN(value) {
+			return fmt.Sprintf("%s %q has invalid checksum", rule.Field, value)
+		}
+	case "url":
+		values := []string{value}
+		if rule.Field == "gallery_images" {
+			values = item.GalleryImages
+		}
+		for _, v := range values {
+			if v != "" && !validURL(v) {
+				return fmt.Sprintf("%s %q is not a valid URL", rule.Field, v)
+			}
+		}
+	case "max_length":
+		if utf8.RuneCountInString(value) > rule.MaxLength {
+			return fmt.Sprintf("%s longer than %d characters", rule.Field, rule.MaxLength)
+		}
+	case "price_range":
+		if value == "" {
+			return ""
+		}
+		price, err := parseNumber(value)
+		if err != nil {
+			return fmt.Sprintf("%s %q is not a number", rule.Field, value)
+		}
+		if rule.Min != nil && price < *rule.Min {
+			return fmt.Sprintf("%s %.2f below minimum %.2f", rule.Field, price, *rule.Min)
+		}
+		if rule.Max != nil && price > *rule.Max {
+			return fmt.Sprintf("%s %.2f above maximum %.2f", rule.Field, price, *rule.Max)
+		}
+	}
+
+	return ""
+}
+
+// validGTIN overí kontrolnú číslicu EAN-8, UPC-A, EAN-13 a GTIN-14
+func validGTIN(code string) bool {
+	code = strings.TrimSpace(code)
+	switch len(code) {
+	case 8, 12, 13, 14:
+	default:
+		return false
+	}
+
+	sum := 0
+	for i := len(code) - 2; i >= 0; i-- {
+		c := code[i]
+		if c < '0' || c > '9' {
+			return false
+		}
+		digit := int(c - '0')
+		// Weights alternate 3,1,3... starting from the digit next to the check digit
+		if (len(code)-2-i)%2 == 0 {
+			digit *= 3
+		}
+		sum += digit
+	}
+
+	check := code[len(code)-1]
+	if check < '0' || check > '9' {
+		return false
+	}
+
+	return (10-sum%10)%10 == int(check-'0')
+}
+
+func validURL(value string) bool {
+	u, err := url.Parse(strings.TrimSpace(value))
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func truncateField(item *models.FeedItem, field string, length int) {
+	cut := func(s string) string {
+		runes := []rune(s)
+		if len(runes) > length {
+			return string(runes[:length])
+		}
+		return s
+	}
+
+	switch field {
+	case "title":
+		item.Title = cut(item.Title)
+	case "description":
+		item.Description = cut(item.Description)
+	case "short_description":
+		item.ShortDescription = cut(item.ShortDescription)
+	case "ean":
+		item.EAN = cut(item.EAN)
+	case "sku":
+		item.SKU = cut(item.SKU)
+	case "mpn":
+		item.MPN = cut(item.MPN)
+	case "external_id":
+		item.ExternalID = cut(item.ExternalID)
+	case "brand":
+		item.Brand = cut(item.Brand)
+	case "manufacturer":
+		item.Manufacturer = cut(item.Manufacturer)
+	case "stock_status":
+		item.StockStatus = cut(item.StockStatus)
+	case "button_text":
+		item.ButtonText = cut(item.ButtonText)
+	case "delivery_time":
+		item.DeliveryTime = cut(item.DeliveryTime)
+	}
+}
@@ -0,0 +1,163 @@
+package importer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"eshopbuilder/internal/models"
+	"eshopbuilder/internal/pricehistory"
+)
+
+// processVariant uloží položku ako variant rodičovského produktu jej skupiny
+func (e *ImportEngine) processVariant(ctx context.Context, item *models.FeedItem) error {
+	parentID, err := e.ensureGroupParent(ctx, item)
+	if err != nil {
+		return fmt.Errorf("variant parent: %w", err)
+	}
+	e.seenProducts[parentID] = true
+
+	key := variantKey(item)
+	newChecksum := e.calculateChecksum(item, checksumFields)
+	fields := fieldChecksums(item)
+
+	var variantID, checksum string
+	var previous map[string]string
+	var active bool
+	e.db.QueryRow(ctx, `
+		SELECT id, COALESCE(feed_checksum, ''), COALESCE(field_checksums, '{}'::jsonb), COALESCE(is_active, true)
+		FROM product_variants
+		WHERE product_id = $1 AND variant_key = $2
+	`, parentID, key).Scan(&variantID, &checksum, &previous, &active)
+
+	// A variant deactivated as missing is written again to reactivate it
+	if variantID != "" && active && checksum == newChecksum {
+		e.seenVariants[variantID] = true
+		e.progress.Skipped++
+		return nil
+	}
+	if variantID != "" {
+		e.countFieldChanges(previous, fields)
+	}
+
+	attrs, _ := json.Marshal(item.Attributes)
+	fieldSums, _ := json.Marshal(fields)
+
+	var savedID string
+	err = e.db.QueryRow(ctx, `
+		INSERT INTO product_variants (
+			product_id, variant_key, title, price, regular_price, sale_price, ean, sku,
+			external_id, image_url, stock_status, stock_quantity, affiliate_url, attributes,
+			feed_checksum, field_checksums, is_active
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, true)
+		ON CONFLICT (product_id, variant_key) DO UPDATE SET
+			title = $3, price = $4, regular_price = $5, sale_price = $6, ean = $7, sku = $8,
+			external_id = $9, image_url = $10, stock_status = $11, stock_quantity = $12,
+			affiliate_url = $13, attributes = $14, feed_checksum = $15, field_checksums = $16,
+			is_active = true, updated_at = NOW()
+		RETURNING id
+	`, parentID, key, item.Title, item.Price,
+		nullIfZero(item.RegularPrice), nullIfZero(item.SalePrice),
+		nullIfEmpty(item.EAN), nullIfEmpty(item.SKU), nullIfEmpty(item.ExternalID),
+		nullIfEmpty(item.ImageURL), coalesce(item.StockStatus, "instock"),
+		nullIfZero(float64(item.StockQuantity)), nullIfEmpty(item.AffiliateURL), attrs, newChecksum, fieldSums).Scan(&savedID)
+	if err != nil {
+		return err
+	}
+	e.seenVariants[savedID] = true
+
+	if variantID != "" {
+		e.progress.Updated++
+	} else {
+		e.progress.Created++
+	}
+
+	return nil
+}
+
+// ensureGroupParent nájde alebo vytvorí rodičovský produkt skupiny variantov.
+// Prvý variant skupiny v behu zároveň aktualizuje spoločné údaje rodiča.
+func (e *ImportEngine) ensureGroupParent(ctx context.Context, item *models.FeedItem) (string, error) {
+	if parentID, ok := e.groupParents[item.ItemGroupID]; ok {
+		return parentID, nil
+	}
+
+	var parentID, checksum string
+	var locked []string
+	e.db.QueryRow(ctx, `
+		SELECT id, COALESCE(feed_checksum, ''), COALESCE(locked_fields, '[]'::jsonb) FROM products
+		WHERE feed_id = $1 AND item_group_id = $2
+		LIMIT 1
+	`, e.feed.ID, item.ItemGroupID).Scan(&parentID, &checksum, &locked)
+
+	parent := *item
+	parent.Attributes = nil
+	writable := e.writableFields(locked)
+	newChecksum := e.calculateChecksum(&parent, writable)
+
+	var categoryID *string
+	if parent.CategoryPath != "" && (parentID == "" || (checksum != newChecksum && containsField(writable, "category"))) {
+		categoryID = e.getOrCreateCategory(ctx, parent.CategoryPath)
+	}
+
+	if parentID == "" {
+		id, err := e.createProduct(ctx, &parent, categoryID, newChecksum)
+		if err != nil {
+			return "", err
+		}
+		parentID = id
+	} else if checksum != newChecksum {
+		if err := e.updateProduct(ctx, parentID, &parent, categoryID, newChecksum, writable); err != nil {
+			return "", err
+		}
+	}
+
+	e.groupParents[item.ItemGroupID] = parentID
+	return parentID, nil
+}
+
+// refreshVariantParents prepočíta cenu a dostupnosť rodičov z ich variantov
+func (e *ImportEngine) refreshVariantParents(ctx context.Context) {
+	if len(e.groupParents) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(e.groupParents))
+	for _, id := range e.groupParents {
+		ids = append(ids, id)
+	}
+
+	_, err := e.db.Exec(ctx, `
+		UPDATE products p SET
+			price = CASE WHEN p.locked_fields ? 'price' THEN p.price ELSE v.min_price END,
+			stock_status = CASE
+				WHEN p.locked_fields ? 'stock_status' THEN p.stock_status
+				WHEN v.in_stock THEN 'instock' ELSE 'outofstock'
+			END,
+			updated_at = NOW()
+		FROM (
+			SELECT product_id, MIN(price) AS min_price, BOOL_OR(stock_status = 'instock') AS in_stock
+			FROM product_variants
+			WHERE is_active = true AND product_id::text = ANY($1)
+			GROUP BY product_id
+		) v
+		WHERE p.id = v.product_id
+	`, ids)
+	if err != nil {
+		e.log("error", "Variant price refresh failed: "+err.Error())
+		return
+	}
+	if err := pricehistory.Record(ctx, e.db, ids, pricehistory.SourceImport); err != nil {
+		e.log("error", "Price history failed: "+err.Error())
+	}
+}
+
+// variantKey - stabilný identifikátor variantu v rámci skupiny
+func variantKey(item *models.FeedItem) string {
+	for _, key := range []string{item.ExternalID, item.SKU, item.EAN} {
+		if key != "" {
+			return key
+		}
+	}
+	return item.Title
+}
@@ -2,15 +2,43 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"strings"
 
+	"eshopbuilder/internal/auth"
+
 	"github.com/golang-jwt/jwt/v5"
 )
 
-func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
+// AuthMiddleware overí access JWT a reláciu v ňom ("sid"); zrušená relácia
+// alebo deaktivovaný používateľ neprejde ani s platným tokenom. Skripty sa
+// namiesto JWT prihlasujú hlavičkou X-API-Key
+func AuthMiddleware(jwtSecret string, sessions *auth.Sessions, apiKeys *auth.APIKeys) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if key := r.Header.Get("X-API-Key"); key != "" {
+				apiKey, err := apiKeys.Authenticate(r, key)
+				switch {
+				case errors.Is(err, auth.ErrInvalidAPIKey):
+					http.Error(w, "Invalid API key", http.StatusUnauthorized)
+					return
+				case errors.Is(err, auth.ErrIPNotAllowed):
+					http.Error(w, "API key not allowed from this IP", http.StatusForbidden)
+					return
+				case err != nil:
+					http.Error(w, "API key check failed", http.StatusInternalServerError)
+					return
+				}
+
+				// No user_id: the key acts only with its own scopes
+				ctx := context.WithValue(r.Context(), "api_key_id", apiKey.ID)
+				ctx = context.WithValue(ctx, "permissions", apiKey.Scopes)
+
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			authHeader := r.Header.Get("Authorization")
 			if authHeader == "" {
 				http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -25,7 +53,7 @@ func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
 
 			token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
 				return []byte(jwtSecret), nil
-			})
+			}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
 
 			if err != nil || !token.Valid {
 				http.Error(w, "Invalid token", http.StatusUnauthorized)
@@ -38,11 +66,52 @@ func AuthMiddleware(jwtSecret string) func(http.Handler) http.Handler {
 				return
 			}
 
-			// Add user info to context
-			ctx := context.WithValue(r.Context(), "user_id", claims["sub"])
-			ctx = context.WithValue(ctx, "user_role", claims["role"])
+			// Tokens issued before sessions existed have no sid and must log in again
+			sessionID, _ := claims["sid"].(string)
+			if sessionID == "" {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			userID, role, active, err := sessions.Check(r.Context(), sessionID)
+			if err != nil {
+				http.Error(w, "Session check failed", http.StatusInternalServerError)
+				return
+			}
+			if !active || userID != claims["sub"] {
+				http.Error(w, "Session expired or revoked", http.StatusUnauthorized)
+				return
+			}
+
+			// Add user info to context; the role comes from the database so changes apply immediately
+			ctx := context.WithValue(r.Context(), "user_id", userID)
+			ctx = context.WithValue(ctx, "user_role", role)
+			ctx = context.WithValue(ctx, "session_id", sessionID)
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
+
+// UserID vráti ID prihláseného používateľa z kontextu (prázdne bez prihlásenia)
+func UserID(ctx context.Context) string {
+	id, _ := ctx.Value("user_id").(string)
+	return id
+}
+
+// SessionID vráti ID relácie prihláseného používateľa z kontextu
+func SessionID(ctx context.Context) string {
+	id, _ := ctx.Value("session_id").(string)
+	return id
+}
+
+// RequireUser odmietne požiadavky s API kľúčom (profil, odhlásenie)
+func RequireUser(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if UserID(r.Context()) == "" {
+			http.Error(w, "Forbidden: requires a user login", http.StatusForbidden)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"eshopbuilder/internal/rbac"
+)
+
+// Permissions vráti oprávnenia z kontextu: scopes API kľúča, inak podľa roly používateľa
+func Permissions(ctx context.Context, roles *rbac.Store) ([]string, error) {
+	if scopes, ok := ctx.Value("permissions").([]string); ok {
+		return scopes, nil
+	}
+	role, _ := ctx.Value("user_role").(string)
+	if role == "" {
+		return nil, nil
+	}
+	return roles.Permissions(ctx, role)
+}
+
+// RequirePermission pustí požiadavku ďalej len s daným oprávnením (za AuthMiddleware)
+func RequirePermission(roles *rbac.Store, permission string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			granted, err := Permissions(r.Context(), roles)
+			if err != nil {
+				http.Error(w, "Permission check failed", http.StatusInternalServerError)
+				return
+			}
+			if !rbac.Allows(granted, permission) {
+				http.Error(w, "Forbidden: missing permission "+permission, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
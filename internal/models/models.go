@@ -15,13 +15,66 @@ type User struct {
 	Email        string     `json:"email" db:"email"`
 	PasswordHash string     `json:"-" db:"password_hash"`
 	Name         string     `json:"name" db:"name"`
-	Role         string     `json:"role" db:"role"` // admin, editor
+	Role         string     `json:"role" db:"role"` // admin, editor, viewer or a custom role
 	IsActive     bool       `json:"is_active" db:"is_active"`
 	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
 	LastLogin    *time.Time `json:"last_login" db:"last_login"`
 }
 
+// APIKey - Kľúč pre skripty a integrácie; samotný kľúč sa vracia len pri vytvorení
+type APIKey struct {
+	ID            string     `json:"id" db:"id"`
+	Name          string     `json:"name" db:"name"`
+	Prefix        string     `json:"prefix" db:"prefix"`
+	Scopes        []string   `json:"scopes" db:"scopes"`
+	AllowedIPs    []string   `json:"allowed_ips" db:"allowed_ips"`
+	CreatedBy     *string    `json:"created_by" db:"created_by"`
+	CreatedByName *string    `json:"created_by_name,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at" db:"expires_at"`
+	LastUsedAt    *time.Time `json:"last_used_at" db:"last_used_at"`
+	LastUsedIP    *string    `json:"last_used_ip" db:"last_used_ip"`
+	RevokedAt     *time.Time `json:"revoked_at" db:"revoked_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	Key           string     `json:"key,omitempty"`
+}
+
+// Session - Prihlásenie na jednom zariadení
+type Session struct {
+	ID         string    `json:"id" db:"id"`
+	UserAgent  *string   `json:"user_agent" db:"user_agent"`
+	IP         *string   `json:"ip" db:"ip"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at" db:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at" db:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+// UserInvitation - Pozvánka do administrácie; token sa vracia len pri vytvorení
+type UserInvitation struct {
+	ID            string     `json:"id" db:"id"`
+	Email         string     `json:"email" db:"email"`
+	Name          *string    `json:"name" db:"name"`
+	Role          string     `json:"role" db:"role"`
+	InvitedBy     *string    `json:"invited_by" db:"invited_by"`
+	InvitedByName *string    `json:"invited_by_name,omitempty"`
+	ExpiresAt     time.Time  `json:"expires_at" db:"expires_at"`
+	AcceptedAt    *time.Time `json:"accepted_at" db:"accepted_at"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	Token         string     `json:"token,omitempty"`
+}
+
+// Role - Pomenovaná sada oprávnení (pozri rbac)
+type Role struct {
+	Name        string    `json:"name" db:"name"`
+	Description *string   `json:"description" db:"description"`
+	Permissions []string  `json:"permissions" db:"permissions"`
+	IsSystem    bool      `json:"is_system" db:"is_system"`
+	UserCount   int       `json:"user_count"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
 // ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
 // PRODUCT
 // ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
@@ -60,9 +113,100 @@ type Product struct {
 	ClickCount       int       `json:"click_count" db:"click_count"`
 	CreatedAt        time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time `json:"updated_at" db:"updated_at"`
+	ItemGroupID      *string   `json:"item_group_id" db:"item_group_id"`
+	BestOfferID      *string   `json:"best_offer_id" db:"best_offer_id"`
+	OfferCount       int       `json:"offer_count" db:"offer_count"`
+	LockedFields     []string  `json:"locked_fields" db:"locked_fields"`
 
 	// Joined fields
-	Category *Category `json:"category,omitempty"`
+	Category *Category        `json:"category,omitempty"`
+	Variants []ProductVariant `json:"variants,omitempty"`
+	Offers   []ProductOffer   `json:"offers,omitempty"`
+	PriceMin *float64         `json:"price_min,omitempty"`
+	PriceMax *float64         `json:"price_max,omitempty"`
+
+	// Highest price in the price-drop window (sort=biggest_drop, price_dropped_since)
+	PriceBefore *float64 `json:"price_before,omitempty"`
+}
+
+// Facets - Počty produktov pre hodnoty filtrov pri aktuálnom výbere
+type Facets struct {
+	Brands       []FacetValue     `json:"brands"`
+	StockStatus  []FacetValue     `json:"stock_status"`
+	DeliveryTime []FacetValue     `json:"delivery_time"`
+	Featured     int              `json:"featured"`
+	Price        PriceRange       `json:"price"`
+	Attributes   []AttributeFacet `json:"attributes"`
+}
+
+// FacetValue - Hodnota fazety s počtom produktov
+type FacetValue struct {
+	Value    string `json:"value"`
+	Count    int    `json:"count"`
+	Selected bool   `json:"selected"`
+}
+
+// AttributeFacet - Hodnoty jedného atribútu (PARAM)
+type AttributeFacet struct {
+	Key    string       `json:"key"`
+	Values []FacetValue `json:"values"`
+}
+
+// PriceRange - Rozsah cien pri aktuálnom výbere
+type PriceRange struct {
+	Min float64 `json:"min"`
+	Max float64 `json:"max"`
+}
+
+// PricePoint - Záznam histórie ceny a dostupnosti
+type PricePoint struct {
+	Price        float64   `json:"price" db:"price"`
+	RegularPrice *float64  `json:"regular_price" db:"regular_price"`
+	SalePrice    *float64  `json:"sale_price" db:"sale_price"`
+	StockStatus  *string   `json:"stock_status" db:"stock_status"`
+	Source       string    `json:"source" db:"source"`
+	RecordedAt   time.Time `json:"recorded_at" db:"recorded_at"`
+}
+
+// ProductOffer - Ponuka jedného feedu (obchodu) pre kanonický produkt
+type ProductOffer struct {
+	ID            string    `json:"id" db:"id"`
+	ProductID     string    `json:"product_id" db:"product_id"`
+	FeedID        string    `json:"feed_id" db:"feed_id"`
+	FeedName      string    `json:"feed_name" db:"feed_name"`
+	Price         float64   `json:"price" db:"price"`
+	RegularPrice  *float64  `json:"regular_price" db:"regular_price"`
+	SalePrice     *float64  `json:"sale_price" db:"sale_price"`
+	Currency      string    `json:"currency" db:"currency"`
+	StockStatus   string    `json:"stock_status" db:"stock_status"`
+	StockQuantity *int      `json:"stock_quantity" db:"stock_quantity"`
+	DeliveryTime  *string   `json:"delivery_time" db:"delivery_time"`
+	AffiliateURL  *string   `json:"affiliate_url" db:"affiliate_url"`
+	ButtonText    *string   `json:"button_text" db:"button_text"`
+	IsActive      bool      `json:"is_active" db:"is_active"`
+	IsBest        bool      `json:"is_best"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ProductVariant - Variant produktu (veľkosť, farba...) zoskupený cez ITEMGROUP_ID
+type ProductVariant struct {
+	ID            string    `json:"id" db:"id"`
+	ProductID     string    `json:"product_id" db:"product_id"`
+	Title         string    `json:"title" db:"title"`
+	Price         float64   `json:"price" db:"price"`
+	RegularPrice  *float64  `json:"regular_price" db:"regular_price"`
+	SalePrice     *float64  `json:"sale_price" db:"sale_price"`
+	EAN           *string   `json:"ean" db:"ean"`
+	SKU           *string   `json:"sku" db:"sku"`
+	ExternalID    *string   `json:"external_id" db:"external_id"`
+	ImageURL      *string   `json:"image_url" db:"image_url"`
+	StockStatus   string    `json:"stock_status" db:"stock_status"`
+	StockQuantity *int      `json:"stock_quantity" db:"stock_quantity"`
+	AffiliateURL  *string   `json:"affiliate_url" db:"affiliate_url"`
+	Attributes    JSONMap   `json:"attributes" db:"attributes"`
+	IsActive      bool      `json:"is_active" db:"is_active"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
@@ -84,6 +228,122 @@ type Category struct {
 	Children     []*Category `json:"children,omitempty"`
 }
 
+// SlugRedirect - Presmerovanie starého slugu produktu alebo kategórie
+type SlugRedirect struct {
+	ID         string     `json:"id" db:"id"`
+	EntityType string     `json:"entity_type" db:"entity_type"` // product, category
+	OldSlug    string     `json:"old_slug" db:"old_slug"`
+	TargetType string     `json:"target_type" db:"target_type"` // product, category
+	TargetSlug string     `json:"target_slug" db:"target_slug"`
+	StatusCode int        `json:"status_code" db:"status_code"`
+	IsManual   bool       `json:"is_manual" db:"is_manual"`
+	Hits       int        `json:"hits" db:"hits"`
+	LastHitAt  *time.Time `json:"last_hit_at" db:"last_hit_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+// SEARCH
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+// SearchSynonym - Skupina rovnocenných výrazov pre rozšírenie dotazu
+type SearchSynonym struct {
+	ID        string    `json:"id" db:"id"`
+	Terms     []string  `json:"terms" db:"terms"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// SearchQueryStats - Súhrn vyhľadávaní jedného dotazu za obdobie
+type SearchQueryStats struct {
+	Query          string    `json:"query"`
+	RawQuery       string    `json:"raw_query"`
+	Searches       int       `json:"searches"`
+	AvgResults     float64   `json:"avg_results"`
+	Clicks         int       `json:"clicks"`
+	CTR            float64   `json:"ctr"`
+	LastSearchedAt time.Time `json:"last_searched_at"`
+}
+
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+// CONVERSIONS
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+// Conversion - Objednávka nahlásená affiliate sieťou k prekliku
+type Conversion struct {
+	ID           string    `json:"id" db:"id"`
+	ClickID      string    `json:"click_id" db:"click_id"`
+	OrderID      string    `json:"order_id" db:"order_id"`
+	Amount       float64   `json:"amount" db:"amount"`
+	Commission   float64   `json:"commission" db:"commission"`
+	Currency     string    `json:"currency" db:"currency"`
+	Status       string    `json:"status" db:"status"` // pending, approved, rejected
+	ProductID    *string   `json:"product_id" db:"product_id"`
+	ProductTitle *string   `json:"product_title,omitempty"`
+	FeedID       *string   `json:"feed_id" db:"feed_id"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ConversionReportRow - Výkon jednej skupiny (produkt, kategória, značka, feed)
+type ConversionReportRow struct {
+	Key             string  `json:"key"`
+	Name            string  `json:"name"`
+	Clicks          int     `json:"clicks"`
+	Conversions     int     `json:"conversions"` // without rejected
+	ConversionRate  float64 `json:"conversion_rate"`
+	OrderValue      float64 `json:"order_value"`
+	Revenue         float64 `json:"revenue"` // commission, pending + approved
+	ApprovedRevenue float64 `json:"approved_revenue"`
+	EPC             float64 `json:"epc"` // revenue per click
+}
+
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+// DASHBOARD
+// ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
+
+// StatsPoint - Jeden interval časového radu (deň, týždeň, mesiac)
+type StatsPoint struct {
+	Period          time.Time `json:"period"`
+	ProductsAdded   int       `json:"products_added"`
+	ProductsRemoved int       `json:"products_removed"`
+	ImportsRun      int       `json:"imports_run"`
+	ImportsFailed   int       `json:"imports_failed"`
+	Views           int       `json:"views"`
+	Clicks          int       `json:"clicks"`
+	Conversions     int       `json:"conversions"`
+	Revenue         float64   `json:"revenue"`
+}
+
+// TopEntry - Položka rebríčka produktov, kategórií alebo značiek
+type TopEntry struct {
+	ID          string  `json:"id"`
+	Name        string  `json:"name"`
+	Views       int     `json:"views"`
+	Clicks      int     `json:"clicks"`
+	Conversions int     `json:"conversions"`
+	Revenue     float64 `json:"revenue"`
+}
+
+// FeedHealth - Stav importov feedu
+type FeedHealth struct {
+	FeedID           string     `json:"feed_id"`
+	Name             string     `json:"name"`
+	Active           bool       `json:"active"`
+	Health           string     `json:"health"` // ok, failing, stale, never, disabled
+	LastRun          *time.Time `json:"last_run"`
+	LastStatus       *string    `json:"last_status"`
+	LastError        *string    `json:"last_error"`
+	LastSuccessAt    *time.Time `json:"last_success_at"`
+	Runs             int        `json:"runs"` // in the requested range
+	Failed           int        `json:"failed"`
+	SuccessRate      float64    `json:"success_rate"`
+	AvgDuration      float64    `json:"avg_duration"` // seconds
+	ActiveOffers     int        `json:"active_offers"`
+	ConsecutiveFails int        `json:"consecutive_fails"`
+}
+
 // ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
 // FEED
 // ━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━
@@ -149,6 +409,71 @@ type Feed struct {
 	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`
 }
 
+// FeedSettings - Typované nastavenia feedu (Feed.Settings)
+type FeedSettings struct {
+	MaxBytes           int64   `json:"max_bytes"`             // download size limit, 0 = global default
+	MaxItemDropPercent float64 `json:"max_item_drop_percent"` // item count drop vs. previous runs, 0 = global default
+	DeactivateMissing  bool    `json:"deactivate_missing"`    // deactivate products no longer in the feed
+
+	// Product fields the import may overwrite on existing products, empty = all
+	UpdateFields []string `json:"update_fields"`
+
+	// Click-out query parameters (UTM, sub-ID), values may use {click_id}, {product_id}, {feed_id}, {slug}, {ean}, {sku}
+	ClickParams map[string]string `json:"click_params"`
+
+	// Affiliate network deep-link wrapping the product URL at click-out
+	AffiliateLink *AffiliateLink `json:"affiliate_link"`
+
+	Filters    []FeedFilter     `json:"filters"`
+	Validation []ValidationRule `json:"validation"`
+}
+
+// AffiliateLink - Šablóna deep-linku affiliate siete
+type AffiliateLink struct {
+	Network      string `json:"network"`       // dognet, cj, awin, ehub; empty = custom template
+	Template     string `json:"template"`      // overrides the network preset
+	PublisherID  string `json:"publisher_id"`  // our ID in the network
+	AdvertiserID string `json:"advertiser_id"` // merchant / campaign ID in the network
+}
+
+// FeedFilter - Filter položiek feedu (include = importuj len zhodné, exclude = preskoč zhodné)
+type FeedFilter struct {
+	Name   string `json:"name"`
+	Action string `json:"action"` // include, exclude
+	FilterGroup
+}
+
+// FilterGroup - Skupina pravidiel spojená cez AND / OR
+type FilterGroup struct {
+	Logic  string        `json:"logic"` // and, or
+	Rules  []FilterRule  `json:"rules"`
+	Groups []FilterGroup `json:"groups"`
+}
+
+// FilterRule - Jedno pravidlo filtra
+type FilterRule struct {
+	Field         string   `json:"field"`    // target field (price, brand, category...), attr:<name> or source:<FEED_FIELD>
+	Operator      string   `json:"operator"` // equals, not_equals, contains, not_contains, starts_with, regex, gt, gte, lt, lte, in, not_in, empty, not_empty
+	Value         string   `json:"value"`
+	Values        []string `json:"values"` // for in / not_in
+	CaseSensitive bool     `json:"case_sensitive"`
+}
+
+// ValidationRule - Validačné pravidlo importu
+type ValidationRule struct {
+	Rule      string   `json:"rule"`     // required, ean_checksum, url, max_length, price_range
+	Field     string   `json:"field"`    // target field key
+	Severity  string   `json:"severity"` // warn, reject
+	MaxLength int      `json:"max_length,omitempty"`
+	Min       *float64 `json:"min,omitempty"`
+	Max       *float64 `json:"max,omitempty"`
+}
+
+const (
+	ValidationWarn   = "warn"
+	ValidationReject = "reject"
+)
+
 type FieldMapping struct {
 	ID             string `json:"id"`
 	SourceField    string `json:"source_field"`
@@ -184,10 +509,19 @@ type ImportHistory struct {
 	Created      int          `json:"created" db:"created"`
 	Updated      int          `json:"updated" db:"updated"`
 	Skipped      int          `json:"skipped" db:"skipped"`
+	Filtered     int          `json:"filtered" db:"filtered"`
 	Errors       int          `json:"errors" db:"errors"`
+	Warnings     int          `json:"warnings" db:"warnings"`
 	Status       ImportStatus `json:"status" db:"status"`
 	ErrorMessage *string      `json:"error_message" db:"error_message"`
 	TriggeredBy  string       `json:"triggered_by" db:"triggered_by"`
+
+	Suspicious       bool    `json:"suspicious" db:"suspicious"`
+	SuspiciousReason *string `json:"suspicious_reason" db:"suspicious_reason"`
+
+	ValidationCounts map[string]int `json:"validation_counts" db:"validation_counts"` // "rule:field" -> failures
+	FieldChanges     map[string]int `json:"field_changes" db:"field_changes"`         // field -> changed items
+	FilterCounts     map[string]int `json:"filter_counts" db:"filter_counts"`         // filter name -> filtered items
 }
 
 type ImportProgress struct {
@@ -200,13 +534,19 @@ type ImportProgress struct {
 	Created     int          `json:"created"`
 	Updated     int          `json:"updated"`
 	Skipped     int          `json:"skipped"`
+	Filtered    int          `json:"filtered"`
 	Errors      int          `json:"errors"`
+	Warnings    int          `json:"warnings"`
 	Message     string       `json:"message"`
 	CurrentItem string       `json:"current_item"`
 	Elapsed     int          `json:"elapsed"`
 	ETA         int          `json:"eta"`
 	Speed       float64      `json:"speed"`
 	Logs        []LogEntry   `json:"logs"`
+
+	ValidationCounts map[string]int `json:"validation_counts"`
+	FieldChanges     map[string]int `json:"field_changes"`
+	FilterCounts     map[string]int `json:"filter_counts"`
 }
 
 type LogEntry struct {
@@ -227,6 +567,7 @@ type FeedItem struct {
 	SKU              string
 	MPN              string
 	ExternalID       string
+	ItemGroupID      string
 	ImageURL         string
 	GalleryImages    []string
 	CategoryPath     string
@@ -326,6 +667,7 @@ var TargetFields = []TargetFieldInfo{
 	{Key: "ean", Label: "EAN / GTIN", Group: "identifiers", Required: false},
 	{Key: "sku", Label: "SKU", Group: "identifiers", Required: false},
 	{Key: "external_id", Label: "External ID", Group: "identifiers", Required: false},
+	{Key: "item_group_id", Label: "Variant Group ID", Group: "identifiers", Required: false},
 	{Key: "title", Label: "Product Name", Group: "basic", Required: true},
 	{Key: "description", Label: "Description", Group: "basic", Required: false},
 	{Key: "short_description", Label: "Short Description", Group: "basic", Required: false},
@@ -344,3 +686,21 @@ var TargetFields = []TargetFieldInfo{
 	{Key: "button_text", Label: "Button Text", Group: "affiliate", Required: false},
 	{Key: "delivery_time", Label: "Delivery Time", Group: "other", Required: false},
 }
+
+// ProductFields - Cieľové polia, ktoré import zapisuje do produktu (dajú sa zamknúť)
+var ProductFields = []string{
+	"title", "description", "short_description", "price", "regular_price", "sale_price",
+	"ean", "sku", "external_id", "image_url", "gallery_images", "category", "brand",
+	"manufacturer", "stock_status", "stock_quantity", "attributes", "affiliate_url",
+	"button_text", "delivery_time",
+}
+
+// IsProductField overí, či ide o pole produktu zapisované importom
+func IsProductField(key string) bool {
+	for _, field := range ProductFields {
+		if field == key {
+			return true
+		}
+	}
+	return false
+}
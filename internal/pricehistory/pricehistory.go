@@ -0,0 +1,60 @@
+package pricehistory
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Zdroje zmien ceny
+const (
+	SourceImport = "import"
+	SourceManual = "manual"
+)
+
+// Record uloží aktuálnu cenu a dostupnosť produktov, ak sa od posledného
+// záznamu zmenila cena, zľavnená cena alebo stav skladu
+func Record(ctx context.Context, db *pgxpool.Pool, productIDs []string, source string) error {
+	if len(productIDs) == 0 {
+		return nil
+	}
+
+	_, err := db.Exec(ctx, `
+		INSERT INTO product_price_history (product_id, price, regular_price, sale_price, stock_status, source)
+		SELECT p.id, p.price, p.regular_price, p.sale_price, p.stock_status, $2
+		FROM products p
+		LEFT JOIN LATERAL (
+			SELECT h.product_id, h.price, h.sale_price, h.stock_status
+			FROM product_price_history h
+			WHERE h.product_id = p.id
+			ORDER BY h.recorded_at DESC
+			LIMIT 1
+		) last ON true
+		WHERE p.id::text = ANY($1)
+			AND (last.product_id IS NULL
+				OR last.price IS DISTINCT FROM p.price
+				OR last.sale_price IS DISTINCT FROM p.sale_price
+				OR last.stock_status IS DISTINCT FROM p.stock_status)
+	`, productIDs, source)
+	return err
+}
+
+// Prune zmaže záznamy staršie ako retentionDays, posledný záznam produktu ponechá.
+// retentionDays <= 0 znamená neobmedzene.
+func Prune(ctx context.Context, db *pgxpool.Pool, retentionDays int) (int64, error) {
+	if retentionDays <= 0 {
+		return 0, nil
+	}
+
+	tag, err := db.Exec(ctx, `
+		DELETE FROM product_price_history h
+		WHERE h.recorded_at < NOW() - make_interval(days => $1)
+			AND h.recorded_at < (
+				SELECT MAX(l.recorded_at) FROM product_price_history l WHERE l.product_id = h.product_id
+			)
+	`, retentionDays)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,142 @@
+package rbac
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Oprávnenia (resource:action); "resource:*" a "*" sú zástupné
+const (
+	DashboardRead   = "dashboard:read"
+	ReportsRead     = "reports:read"
+	ProductsRead    = "products:read"
+	ProductsWrite   = "products:write"
+	CategoriesRead  = "categories:read"
+	CategoriesWrite = "categories:write"
+	RedirectsRead   = "redirects:read"
+	RedirectsWrite  = "redirects:write"
+	FeedsRead       = "feeds:read"
+	FeedsWrite      = "feeds:write"
+	FeedsImport     = "feeds:import"
+	SearchRead      = "search:read"
+	SearchWrite     = "search:write"
+	SettingsRead    = "settings:read"
+	SettingsWrite   = "settings:write"
+	UsersManage     = "users:manage"
+	RolesManage     = "roles:manage"
+	APIKeysManage   = "api_keys:manage"
+)
+
+// Wildcard - všetky oprávnenia
+const Wildcard = "*"
+
+// Vstavané roly
+const (
+	RoleAdmin  = "admin"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+)
+
+// Permissions - všetky známe oprávnenia
+var Permissions = []string{
+	DashboardRead, ReportsRead, ProductsRead, ProductsWrite, CategoriesRead, CategoriesWrite,
+	RedirectsRead, RedirectsWrite, FeedsRead, FeedsWrite, FeedsImport, SearchRead, SearchWrite,
+	SettingsRead, SettingsWrite, UsersManage, RolesManage, APIKeysManage,
+}
+
+// Valid overí oprávnenie vrátane zástupných ("feeds:*", "*")
+func Valid(permission string) bool {
+	if permission == Wildcard {
+		return true
+	}
+	resource, action, ok := strings.Cut(permission, ":")
+	for _, known := range Permissions {
+		if known == permission {
+			return true
+		}
+		if ok && action == "*" && strings.HasPrefix(known, resource+":") {
+			return true
+		}
+	}
+	return false
+}
+
+// Allows overí, či pridelené oprávnenia pokrývajú požadované
+func Allows(granted []string, permission string) bool {
+	resource, _, _ := strings.Cut(permission, ":")
+	for _, g := range granted {
+		if g == Wildcard || g == permission || g == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheTTL - zmeny rolí z iných inštancií sa prejavia najneskôr po tomto čase
+const cacheTTL = time.Minute
+
+// Store - Oprávnenia rolí z tabuľky roles s krátkou cache
+type Store struct {
+	db *pgxpool.Pool
+
+	mu     sync.RWMutex
+	roles  map[string][]string
+	loaded time.Time
+}
+
+func NewStore(db *pgxpool.Pool) *Store {
+	return &Store{db: db}
+}
+
+// Permissions vráti oprávnenia roly; neznáma rola nemá žiadne
+func (s *Store) Permissions(ctx context.Context, role string) ([]string, error) {
+	s.mu.RLock()
+	if s.roles != nil && time.Since(s.loaded) < cacheTTL {
+		permissions := s.roles[role]
+		s.mu.RUnlock()
+		return permissions, nil
+	}
+	s.mu.RUnlock()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.roles == nil || time.Since(s.loaded) >= cacheTTL {
+		roles, err := s.load(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.roles = roles
+		s.loaded = time.Now()
+	}
+	return s.roles[role], nil
+}
+
+// Invalidate vynúti opätovné načítanie rolí
+func (s *Store) Invalidate() {
+	s.mu.Lock()
+	s.roles = nil
+	s.mu.Unlock()
+}
+
+func (s *Store) load(ctx context.Context) (map[string][]string, error) {
+	rows, err := s.db.Query(ctx, "SELECT name, permissions FROM roles")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := make(map[string][]string)
+	for rows.Next() {
+		var name string
+		var permissions []string
+		if err := rows.Scan(&name, &permissions); err != nil {
+			return nil, err
+		}
+		roles[name] = permissions
+	}
+	return roles, rows.Err()
+}
@@ -0,0 +1,85 @@
+package redirects
+
+import (
+	"context"
+
+	"eshopbuilder/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Typy stránok, medzi ktorými sa presmerúva
+const (
+	TypeProduct  = "product"
+	TypeCategory = "category"
+)
+
+// Beginner - *pgxpool.Pool alebo pgx.Tx (Record potom beží v jej transakcii)
+type Beginner interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+}
+
+// Record zaznamená presmerovanie starého slugu na nový cieľ. Existujúce presmerovania
+// na starý slug sa prepoja priamo na nový cieľ, aby nevznikali reťaze.
+func Record(ctx context.Context, db Beginner, entityType, oldSlug, targetType, targetSlug string) error {
+	if oldSlug == "" || targetSlug == "" || (entityType == targetType && oldSlug == targetSlug) {
+		return nil
+	}
+
+	tx, err := db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// The target URL is live, it must not redirect anymore
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM slug_redirects WHERE entity_type = $1 AND old_slug = $2
+	`, targetType, targetSlug); err != nil {
+		return err
+	}
+
+	// Collapse chains: a -> old becomes a -> target
+	if _, err := tx.Exec(ctx, `
+		UPDATE slug_redirects SET target_type = $3, target_slug = $4, updated_at = NOW()
+		WHERE target_type = $1 AND target_slug = $2
+	`, entityType, oldSlug, targetType, targetSlug); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO slug_redirects (entity_type, old_slug, target_type, target_slug)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (entity_type, old_slug) DO UPDATE SET
+			target_type = $3, target_slug = $4, is_manual = false, updated_at = NOW()
+	`, entityType, oldSlug, targetType, targetSlug); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// Lookup nájde presmerovanie pre neexistujúci slug a započíta návštevu
+func Lookup(ctx context.Context, db *pgxpool.Pool, entityType, slug string) (*models.SlugRedirect, error) {
+	var rd models.SlugRedirect
+	err := db.QueryRow(ctx, `
+		UPDATE slug_redirects SET hits = hits + 1, last_hit_at = NOW()
+		WHERE entity_type = $1 AND old_slug = $2
+		RETURNING id, entity_type, old_slug, target_type, target_slug, status_code,
+			is_manual, hits, last_hit_at, created_at, updated_at
+	`, entityType, slug).Scan(&rd.ID, &rd.EntityType, &rd.OldSlug, &rd.TargetType, &rd.TargetSlug,
+		&rd.StatusCode, &rd.IsManual, &rd.Hits, &rd.LastHitAt, &rd.CreatedAt, &rd.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &rd, nil
+}
+
+// Path vráti cestu verejného API pre cieľ presmerovania
+func Path(targetType, slug string) string {
+	if targetType == TypeCategory {
+		return "/api/v1/categories/" + slug
+	}
+	return "/api/v1/products/" + slug
+}
@@ -0,0 +1,150 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// dictionaryTTL - Ako dlho platia synonymá a stop slová načítané z databázy
+const dictionaryTTL = 5 * time.Minute
+
+// dictionary - Synonymá a stop slová pre rozšírenie dotazu
+type dictionary struct {
+	synonyms  map[string][]string // normalized word -> all terms of its group
+	stopwords map[string]bool
+	loaded    time.Time
+}
+
+type dictionaryCache struct {
+	mu   sync.Mutex
+	dict *dictionary
+}
+
+// Parse spracuje dotaz a rozšíri ho o synonymá; stop slová vynechá,
+// ak dotaz neobsahuje len ich
+func (s *Searcher) Parse(ctx context.Context, text string) Query {
+	q := Parse(text)
+	if q.Empty() {
+		return q
+	}
+
+	dict := s.dictionary(ctx)
+
+	tokens := []string{}
+	for _, token := range q.tokens {
+		if !dict.stopwords[normalize(token)] {
+			tokens = append(tokens, token)
+		}
+	}
+	if len(tokens) == 0 {
+		tokens = q.tokens
+	}
+
+	terms := make([]string, len(tokens))
+	for i, token := range tokens {
+		group, ok := dict.synonyms[normalize(token)]
+		if !ok {
+			terms[i] = token + ":*"
+			continue
+		}
+		alternatives := []string{token + ":*"}
+		for _, synonym := range group {
+			if synonym != normalize(token) {
+				alternatives = append(alternatives, phrase(synonym))
+			}
+		}
+		terms[i] = "(" + strings.Join(alternatives, " | ") + ")"
+	}
+
+	return Query{
+		Text:    strings.Join(tokens, " "),
+		TSQuery: strings.Join(terms, " & "),
+		tokens:  tokens,
+	}
+}
+
+// InvalidateDictionary vynúti opätovné načítanie synoným a stop slov
+func (s *Searcher) InvalidateDictionary() {
+	s.dict.mu.Lock()
+	s.dict.dict = nil
+	s.dict.mu.Unlock()
+	s.cache.clear()
+}
+
+func (s *Searcher) dictionary(ctx context.Context) *dictionary {
+	s.dict.mu.Lock()
+	defer s.dict.mu.Unlock()
+
+	if s.dict.dict != nil && time.Since(s.dict.dict.loaded) < dictionaryTTL {
+		return s.dict.dict
+	}
+
+	dict := &dictionary{
+		synonyms:  make(map[string][]string),
+		stopwords: make(map[string]bool),
+		loaded:    time.Now(),
+	}
+
+	rows, err := s.db.Query(ctx, "SELECT terms FROM search_synonyms")
+	failed := err != nil
+	if err == nil {
+		for rows.Next() {
+			var terms []string
+			rows.Scan(&terms)
+			group := []string{}
+			for _, term := range terms {
+				if t := strings.Join(tokenize(normalize(term)), " "); t != "" {
+					group = append(group, t)
+				}
+			}
+			for _, term := range group {
+				if !strings.Contains(term, " ") {
+					dict.synonyms[term] = group
+				}
+			}
+		}
+		rows.Close()
+	}
+
+	rows, err = s.db.Query(ctx, "SELECT word FROM search_stopwords")
+	failed = failed || err != nil
+	if err == nil {
+		for rows.Next() {
+			var word string
+			rows.Scan(&word)
+			dict.stopwords[normalize(word)] = true
+		}
+		rows.Close()
+	}
+
+	// Keep the previous dictionary when the database is unavailable
+	if failed && s.dict.dict != nil {
+		return s.dict.dict
+	}
+
+	s.dict.dict = dict
+	return dict
+}
+
+// phrase - viacslovné synonymum ako frázový tsquery s prefixom posledného slova
+func phrase(term string) string {
+	words := strings.Fields(term)
+	words[len(words)-1] += ":*"
+	return strings.Join(words, " <-> ")
+}
+
+// normalize - malé písmená bez diakritiky ("Smartfón" -> "smartfon")
+func normalize(text string) string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(strings.TrimSpace(text))) {
+		if !unicode.Is(unicode.Mn, r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,41 @@
+package search
+
+import (
+	"context"
+	"strings"
+)
+
+// Zdroje záznamov v search_log
+const (
+	SourceSearch  = "search"
+	SourceListing = "listing"
+)
+
+// Log zapíše vyhľadávanie do search_log; dotaz sa ukladá normalizovaný
+// vrátane stop slov, aby štatistiky zodpovedali tomu, čo návštevník napísal
+func (s *Searcher) Log(ctx context.Context, id, raw string, results int, source string) error {
+	query := normalize(strings.Join(tokenize(raw), " "))
+	raw = strings.TrimSpace(raw)
+	if query == "" || len(query) > 255 || len(raw) > 255 {
+		return nil
+	}
+
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO search_log (id, query, raw_query, result_count, source)
+		VALUES ($1, $2, $3, $4, $5)
+	`, id, query, raw, results, source)
+	return err
+}
+
+// Click priradí k vyhľadávaniu produkt, na ktorý návštevník klikol (prvý klik)
+func (s *Searcher) Click(ctx context.Context, searchID, productID string) (bool, error) {
+	result, err := s.db.Exec(ctx, `
+		UPDATE search_log SET clicked_product_id = $2, clicked_at = NOW()
+		WHERE id = $1 AND clicked_product_id IS NULL
+			AND EXISTS (SELECT 1 FROM products WHERE id = $2)
+	`, searchID, productID)
+	if err != nil {
+		return false, err
+	}
+	return result.RowsAffected() > 0, nil
+}
@@ -0,0 +1,151 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"eshopbuilder/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Config - Text search konfigurácia z migrácie (simple + unaccent)
+const Config = "sk_unaccent"
+
+// maxTokens - Dlhšie dotazy sa orežú
+const maxTokens = 8
+
+// Query - Spracovaný vyhľadávací dotaz
+type Query struct {
+	Text    string // normalizované slová pre trigramovú podobnosť
+	TSQuery string // prefixový tsquery, napr. "iphon:* & 13:*"
+
+	tokens []string
+}
+
+// Parse rozdelí text na slová a zostaví prefixový tsquery (bez synoným, pozri Searcher.Parse)
+func Parse(text string) Query {
+	tokens := tokenize(text)
+	if len(tokens) > maxTokens {
+		tokens = tokens[:maxTokens]
+	}
+
+	terms := make([]string, len(tokens))
+	for i, token := range tokens {
+		terms[i] = token + ":*"
+	}
+
+	return Query{
+		Text:    strings.Join(tokens, " "),
+		TSQuery: strings.Join(terms, " & "),
+		tokens:  tokens,
+	}
+}
+
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Empty - dotaz bez hľadateľných slov
+func (q Query) Empty() bool {
+	return q.TSQuery == ""
+}
+
+// Args vráti parametre pre SQL z Match v poradí tsquery, text
+func (q Query) Args() []interface{} {
+	return []interface{}{q.TSQuery, q.Text}
+}
+
+// Match vráti SQL podmienku a výraz relevancie pre tabuľku products pod aliasom.
+// arg je číslo parametra tsquery, text nasleduje hneď za ním (pozri Args).
+func (q Query) Match(alias string, arg int) (where, rank string) {
+	tsquery := fmt.Sprintf("to_tsquery('%s', $%d)", Config, arg)
+	text := fmt.Sprintf("f_unaccent(lower($%d))", arg+1)
+
+	where = fmt.Sprintf("(%[1]s.search_vector @@ %[2]s OR f_unaccent(lower(%[1]s.title)) %%> %[3]s)",
+		alias, tsquery, text)
+	rank = fmt.Sprintf("((ts_rank_cd(%[1]s.search_vector, %[2]s, 32) + word_similarity(%[3]s, f_unaccent(lower(%[1]s.title)))) * %[4]s)",
+		alias, tsquery, text, Popularity(alias))
+	return where, rank
+}
+
+// Popularity - násobiteľ relevancie podľa nedávnych zobrazení a preklikov (pozri stats.RefreshPopularity)
+func Popularity(alias string) string {
+	return fmt.Sprintf("(1 + ln(1 + COALESCE(%s.popularity_score, 0)) / 10)", alias)
+}
+
+// Result - Produkt vo výsledkoch so zvýraznením
+type Result struct {
+	models.Product
+	Rank     float64 `json:"rank"`
+	Headline string  `json:"headline"` // title with <mark> highlights
+	Snippet  string  `json:"snippet"`  // description fragments with <mark> highlights
+}
+
+// Searcher - Fulltextové vyhľadávanie produktov
+type Searcher struct {
+	db    *pgxpool.Pool
+	cache suggestCache
+	dict  dictionaryCache
+}
+
+func New(db *pgxpool.Pool) *Searcher {
+	return &Searcher{db: db}
+}
+
+// Search vráti stránku výsledkov zoradenú podľa relevancie a celkový počet
+func (s *Searcher) Search(ctx context.Context, q Query, limit, offset int) ([]Result, int, error) {
+	results := []Result{}
+	if q.Empty() {
+		return results, 0, nil
+	}
+
+	where, rank := q.Match("p", 1)
+
+	var total int
+	if err := s.db.QueryRow(ctx, `
+		SELECT COUNT(*) FROM products p WHERE p.is_active = true AND `+where,
+		q.Args()...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	// Headlines are expensive, only build them for the returned page
+	rows, err := s.db.Query(ctx, `
+		WITH matched AS (
+			SELECT p.id, `+rank+` AS rank
+			FROM products p
+			WHERE p.is_active = true AND `+where+`
+			ORDER BY rank DESC, p.id
+			LIMIT $3 OFFSET $4
+		)
+		SELECT p.id, p.slug, p.title, p.price, p.regular_price, p.sale_price, p.image_url,
+			p.brand, p.stock_status, p.offer_count, m.rank,
+			ts_headline('`+Config+`', p.title, to_tsquery('`+Config+`', $1),
+				'HighlightAll=true, StartSel=<mark>, StopSel=</mark>'),
+			ts_headline('`+Config+`', COALESCE(NULLIF(p.short_description, ''), LEFT(COALESCE(p.description, ''), 2000)),
+				to_tsquery('`+Config+`', $1),
+				'MaxWords=30, MinWords=10, MaxFragments=2, StartSel=<mark>, StopSel=</mark>')
+		FROM matched m
+		JOIN products p ON p.id = m.id
+		ORDER BY m.rank DESC, p.id
+	`, q.TSQuery, q.Text, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r Result
+		if err := rows.Scan(&r.ID, &r.Slug, &r.Title, &r.Price, &r.RegularPrice, &r.SalePrice,
+			&r.ImageURL, &r.Brand, &r.StockStatus, &r.OfferCount, &r.Rank, &r.Headline, &r.Snippet); err != nil {
+			return nil, 0, err
+		}
+		results = append(results, r)
+	}
+
+	return results, total, rows.Err()
+}
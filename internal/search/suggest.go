@@ -0,0 +1,239 @@
+package search
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	suggestLimit      = 5
+	suggestCacheTTL   = time.Minute
+	suggestCacheLimit = 10000
+)
+
+// Suggestions - Návrhy pre vyhľadávacie pole zoskupené podľa typu
+type Suggestions struct {
+	Query       string               `json:"query"`
+	Completions []string             `json:"completions"`
+	Categories  []CategorySuggestion `json:"categories"`
+	Brands      []BrandSuggestion    `json:"brands"`
+	Products    []ProductSuggestion  `json:"products"`
+}
+
+type CategorySuggestion struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Slug         string `json:"slug"`
+	ProductCount int    `json:"product_count"`
+}
+
+type BrandSuggestion struct {
+	Name         string `json:"name"`
+	ProductCount int    `json:"product_count"`
+}
+
+type ProductSuggestion struct {
+	ID        string   `json:"id"`
+	Slug      string   `json:"slug"`
+	Title     string   `json:"title"`
+	ImageURL  *string  `json:"image_url"`
+	Price     float64  `json:"price"`
+	SalePrice *float64 `json:"sale_price"`
+}
+
+// suggestCache - Krátkodobá cache návrhov podľa normalizovaného dotazu
+type suggestCache struct {
+	mu      sync.RWMutex
+	entries map[string]cachedSuggestions
+}
+
+type cachedSuggestions struct {
+	value   *Suggestions
+	expires time.Time
+}
+
+func (c *suggestCache) get(key string) (*Suggestions, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *suggestCache) set(key string, value *Suggestions) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil || len(c.entries) >= suggestCacheLimit {
+		// Drop expired entries, start over if the cache is still full
+		now := time.Now()
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+		if c.entries == nil || len(c.entries) >= suggestCacheLimit {
+			c.entries = make(map[string]cachedSuggestions)
+		}
+	}
+	c.entries[key] = cachedSuggestions{value: value, expires: time.Now().Add(suggestCacheTTL)}
+}
+
+func (c *suggestCache) clear() {
+	c.mu.Lock()
+	c.entries = nil
+	c.mu.Unlock()
+}
+
+// Suggest vráti návrhy pre rozpísaný dotaz; jednotlivé skupiny sa hľadajú súbežne
+func (s *Searcher) Suggest(ctx context.Context, q Query) (*Suggestions, error) {
+	if cached, ok := s.cache.get(q.Text); ok {
+		return cached, nil
+	}
+
+	result := &Suggestions{
+		Query:       q.Text,
+		Completions: []string{},
+		Categories:  []CategorySuggestion{},
+		Brands:      []BrandSuggestion{},
+		Products:    []ProductSuggestion{},
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	run := func(i int, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = fn()
+		}()
+	}
+
+	run(0, func() error { return s.suggestCompletions(ctx, q, result) })
+	run(1, func() error { return s.suggestCategories(ctx, q, result) })
+	run(2, func() error { return s.suggestBrands(ctx, q, result) })
+	run(3, func() error { return s.suggestProducts(ctx, q, result) })
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.cache.set(q.Text, result)
+	return result, nil
+}
+
+func (s *Searcher) suggestCompletions(ctx context.Context, q Query, result *Suggestions) error {
+	rows, err := s.db.Query(ctx, `
+		SELECT display FROM popular_queries
+		WHERE query LIKE f_unaccent($1) || '%' AND result_count > 0
+		ORDER BY search_count DESC
+		LIMIT $2
+	`, q.Text, suggestLimit)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var completion string
+		if err := rows.Scan(&completion); err != nil {
+			return err
+		}
+		result.Completions = append(result.Completions, completion)
+	}
+	return rows.Err()
+}
+
+func (s *Searcher) suggestCategories(ctx context.Context, q Query, result *Suggestions) error {
+	rows, err := s.db.Query(ctx, `
+		SELECT id, name, slug, product_count FROM categories
+		WHERE is_active = true AND product_count > 0
+			AND f_unaccent(lower(name)) LIKE '%' || f_unaccent($1) || '%'
+		ORDER BY (f_unaccent(lower(name)) LIKE f_unaccent($1) || '%') DESC, product_count DESC
+		LIMIT $2
+	`, q.Text, suggestLimit)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c CategorySuggestion
+		if err := rows.Scan(&c.ID, &c.Name, &c.Slug, &c.ProductCount); err != nil {
+			return err
+		}
+		result.Categories = append(result.Categories, c)
+	}
+	return rows.Err()
+}
+
+func (s *Searcher) suggestBrands(ctx context.Context, q Query, result *Suggestions) error {
+	rows, err := s.db.Query(ctx, `
+		SELECT brand, COUNT(*) FROM products
+		WHERE is_active = true AND f_unaccent(lower(brand)) LIKE '%' || f_unaccent($1) || '%'
+		GROUP BY brand
+		ORDER BY (f_unaccent(lower(brand)) LIKE f_unaccent($1) || '%') DESC, COUNT(*) DESC
+		LIMIT $2
+	`, q.Text, suggestLimit)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var b BrandSuggestion
+		if err := rows.Scan(&b.Name, &b.ProductCount); err != nil {
+			return err
+		}
+		result.Brands = append(result.Brands, b)
+	}
+	return rows.Err()
+}
+
+func (s *Searcher) suggestProducts(ctx context.Context, q Query, result *Suggestions) error {
+	where, rank := q.Match("p", 1)
+	rows, err := s.db.Query(ctx, `
+		SELECT p.id, p.slug, p.title, p.image_url, p.price, p.sale_price
+		FROM products p
+		WHERE p.is_active = true AND `+where+`
+		ORDER BY `+rank+` DESC
+		LIMIT $3
+	`, q.TSQuery, q.Text, suggestLimit)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p ProductSuggestion
+		if err := rows.Scan(&p.ID, &p.Slug, &p.Title, &p.ImageURL, &p.Price, &p.SalePrice); err != nil {
+			return err
+		}
+		result.Products = append(result.Products, p)
+	}
+	return rows.Err()
+}
+
+// RecordQuery započíta hľadaný výraz do populárnych dotazov (pre doplňovanie)
+func (s *Searcher) RecordQuery(ctx context.Context, q Query, raw string, results int) error {
+	display := strings.TrimSpace(raw)
+	if len([]rune(q.Text)) < 2 || len(display) > 255 {
+		return nil
+	}
+
+	_, err := s.db.Exec(ctx, `
+		INSERT INTO popular_queries (query, display, search_count, result_count, last_searched_at)
+		VALUES (f_unaccent($1), $2, 1, $3, NOW())
+		ON CONFLICT (query) DO UPDATE SET
+			display = $2, search_count = popular_queries.search_count + 1,
+			result_count = $3, last_searched_at = NOW()
+	`, q.Text, display, results)
+	return err
+}
@@ -0,0 +1,146 @@
+package slug
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxLength - Maximálna dĺžka slugu bez číselnej prípony
+const MaxLength = 200
+
+// transliterations - Znaky, ktoré sa rozkladom (NFD) nezmenia na ASCII
+var transliterations = map[rune]string{
+	// Latin
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d", 'ł': "l",
+	'þ': "th", 'ı': "i", 'ŋ': "ng", 'ħ': "h", 'ŧ': "t", 'ĸ': "k",
+	'&': " and ", '@': " at ", '+': " plus ",
+	// Cyrillic
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'ґ': "g", 'д': "d", 'е': "e", 'ё': "e",
+	'є': "ye", 'ж': "zh", 'з': "z", 'и': "i", 'і': "i", 'ї': "yi", 'й': "y",
+	'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o", 'п': "p", 'р': "r",
+	'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch",
+	'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	// Greek
+	'α': "a", 'β': "v", 'γ': "g", 'δ': "d", 'ε': "e", 'ζ': "z", 'η': "i",
+	'θ': "th", 'ι': "i", 'κ': "k", 'λ': "l", 'μ': "m", 'ν': "n", 'ξ': "x",
+	'ο': "o", 'π': "p", 'ρ': "r", 'σ': "s", 'ς': "s", 'τ': "t", 'υ': "y",
+	'φ': "f", 'χ': "ch", 'ψ': "ps", 'ω': "o",
+}
+
+// Querier - *pgxpool.Pool alebo pgx.Tx (kontrola v transakcii zmeny slugu)
+type Querier interface {
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+}
+
+// tables - Tabuľky so stĺpcom slug a náhradný slug pre text bez písmen
+var tables = map[string]string{
+	"products":   "product",
+	"categories": "category",
+}
+
+// Make vytvorí slug: malé písmená, prepis do ASCII, pomlčky medzi slovami
+func Make(text string) string {
+	var b strings.Builder
+	dash := false
+
+	write := func(s string) {
+		for _, r := range s {
+			if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				if dash && b.Len() > 0 {
+					b.WriteByte('-')
+				}
+				b.WriteRune(r)
+				dash = false
+			} else {
+				dash = true
+			}
+		}
+	}
+
+	// NFD splits "č" into "c" + combining caron, marks are dropped
+	for _, r := range norm.NFD.String(strings.ToLower(text)) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if t, ok := transliterations[r]; ok {
+			write(t)
+			continue
+		}
+		write(string(r))
+	}
+
+	s := b.String()
+	if len(s) > MaxLength {
+		s = strings.TrimRight(s[:MaxLength], "-")
+	}
+	return s
+}
+
+// Unique vráti slug z textu, ktorý v tabuľke ešte neexistuje; pri kolízii pridá
+// príponu -2, -3... Riadok excludeID (upravovaný záznam) sa ignoruje.
+func Unique(ctx context.Context, db Querier, table, text, excludeID string) (string, error) {
+	fallback, ok := tables[table]
+	if !ok {
+		return "", fmt.Errorf("slug: unsupported table %q", table)
+	}
+
+	base := Make(text)
+	if base == "" {
+		base = fallback
+	}
+
+	rows, err := db.Query(ctx, `
+		SELECT slug FROM `+table+`
+		WHERE (slug = $1 OR slug LIKE $2) AND id::text <> $3
+	`, base, escapeLike(base)+"-%", excludeID)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	taken := make(map[int]bool)
+	for rows.Next() {
+		var existing string
+		if err := rows.Scan(&existing); err != nil {
+			return "", err
+		}
+		if existing == base {
+			taken[1] = true
+			continue
+		}
+		if n, err := strconv.Atoi(strings.TrimPrefix(existing, base+"-")); err == nil {
+			taken[n] = true
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+
+	if !taken[1] {
+		return base, nil
+	}
+	for n := 2; ; n++ {
+		if !taken[n] {
+			return base + "-" + strconv.Itoa(n), nil
+		}
+	}
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// IsConflict overí, či chyba vznikla porušením unikátnosti slugu
+// (súbežné vloženie rovnakého slugu)
+func IsConflict(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505" && strings.Contains(pgErr.ConstraintName, "slug")
+}
@@ -0,0 +1,103 @@
+package sqlbuilder
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Placeholder - Značka parametra v podmienkach, pri zostavení sa prečísluje na $n
+const Placeholder = "$?"
+
+// Builder - Zbiera parametre jedného SQL dotazu a čísluje ich
+type Builder struct {
+	args []interface{}
+}
+
+// Arg pridá parameter a vráti jeho značku ($n)
+func (b *Builder) Arg(value interface{}) string {
+	b.args = append(b.args, value)
+	return "$" + strconv.Itoa(len(b.args))
+}
+
+// Add pridá parametre a vráti číslo prvého z nich
+func (b *Builder) Add(values ...interface{}) int {
+	first := len(b.args) + 1
+	b.args = append(b.args, values...)
+	return first
+}
+
+// Args vráti parametre v poradí čísel
+func (b *Builder) Args() []interface{} {
+	return b.args
+}
+
+// Bind nahradí značky $? v podmienke parametrami
+func (b *Builder) Bind(cond string, values ...interface{}) string {
+	var out strings.Builder
+	for _, value := range values {
+		i := strings.Index(cond, Placeholder)
+		if i < 0 {
+			break
+		}
+		out.WriteString(cond[:i])
+		out.WriteString(b.Arg(value))
+		cond = cond[i+len(Placeholder):]
+	}
+	out.WriteString(cond)
+	return out.String()
+}
+
+// Filters - Pomenované podmienky WHERE; názov umožňuje podmienku vynechať
+// (napr. pri počítaní faziet pre práve filtrované pole)
+type Filters struct {
+	items []filter
+}
+
+type filter struct {
+	name   string
+	render func(b *Builder) string
+}
+
+// Add pridá podmienku so značkami $? pre parametre
+func (f *Filters) Add(name, cond string, values ...interface{}) {
+	f.AddFunc(name, func(b *Builder) string {
+		return b.Bind(cond, values...)
+	})
+}
+
+// AddFunc pridá podmienku, ktorú zostaví funkcia (napr. s vlastným číslovaním)
+func (f *Filters) AddFunc(name string, render func(b *Builder) string) {
+	f.items = append(f.items, filter{name: name, render: render})
+}
+
+// Has overí, či existuje podmienka s daným názvom
+func (f *Filters) Has(name string) bool {
+	for _, item := range f.items {
+		if item.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Render spojí podmienky cez AND, okrem vynechaných názvov
+func (f *Filters) Render(b *Builder, exclude ...string) string {
+	conds := []string{}
+	for _, item := range f.items {
+		skip := false
+		for _, name := range exclude {
+			if item.name == name {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			conds = append(conds, item.render(b))
+		}
+	}
+
+	if len(conds) == 0 {
+		return "true"
+	}
+	return strings.Join(conds, " AND ")
+}
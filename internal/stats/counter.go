@@ -0,0 +1,180 @@
+package stats
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// popularityInterval - Ako často sa prepočítava popularita produktov
+const popularityInterval = 15 * time.Minute
+
+type bucket struct {
+	productID string
+	day       string // YYYY-MM-DD
+}
+
+type counts struct {
+	views  int
+	clicks int
+}
+
+// Counter - Zbiera zobrazenia a preklíky v pamäti a periodicky ich zapisuje
+// do products a product_stats_daily jedným dávkovým dotazom
+type Counter struct {
+	db       *pgxpool.Pool
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[bucket]*counts
+}
+
+func NewCounter(db *pgxpool.Pool, interval time.Duration) *Counter {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &Counter{
+		db:       db,
+		interval: interval,
+		pending:  make(map[bucket]*counts),
+	}
+}
+
+// View započíta zobrazenie produktu
+func (c *Counter) View(productID string) {
+	c.add(productID, 1, 0)
+}
+
+// Click započíta preklik produktu
+func (c *Counter) Click(productID string) {
+	c.add(productID, 0, 1)
+}
+
+func (c *Counter) add(productID string, views, clicks int) {
+	key := bucket{productID: productID, day: time.Now().Format("2006-01-02")}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := c.pending[key]
+	if n == nil {
+		n = &counts{}
+		c.pending[key] = n
+	}
+	n.views += views
+	n.clicks += clicks
+}
+
+// Run zapisuje počty každý interval a prepočítava popularitu; po zrušení ctx
+// vykoná posledný zápis a skončí
+func (c *Counter) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+	lastPopularity := time.Time{}
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := c.Flush(flushCtx); err != nil {
+				log.Printf("Stats flush failed: %v", err)
+			}
+			cancel()
+			return
+		case <-ticker.C:
+			if err := c.Flush(ctx); err != nil {
+				log.Printf("Stats flush failed: %v", err)
+			}
+			if time.Since(lastPopularity) >= popularityInterval {
+				if err := RefreshPopularity(ctx, c.db); err != nil {
+					log.Printf("Popularity refresh failed: %v", err)
+				}
+				lastPopularity = time.Now()
+			}
+		}
+	}
+}
+
+// Flush zapíše nazbierané počty; pri chybe ich vráti späť do bufferu
+func (c *Counter) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[bucket]*counts)
+	c.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(pending))
+	days := make([]string, 0, len(pending))
+	views := make([]int, 0, len(pending))
+	clicks := make([]int, 0, len(pending))
+	for key, n := range pending {
+		ids = append(ids, key.productID)
+		days = append(days, key.day)
+		views = append(views, n.views)
+		clicks = append(clicks, n.clicks)
+	}
+
+	if err := c.write(ctx, ids, days, views, clicks); err != nil {
+		c.mu.Lock()
+		for key, n := range pending {
+			c.restore(key, n)
+		}
+		c.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// restore vráti neuložené počty do bufferu (volá sa pod zámkom)
+func (c *Counter) restore(key bucket, n *counts) {
+	if existing := c.pending[key]; existing != nil {
+		existing.views += n.views
+		existing.clicks += n.clicks
+		return
+	}
+	c.pending[key] = n
+}
+
+func (c *Counter) write(ctx context.Context, ids, days []string, views, clicks []int) error {
+	tx, err := c.db.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Products deleted since the event are skipped
+	_, err = tx.Exec(ctx, `
+		INSERT INTO product_stats_daily (product_id, day, views, clicks)
+		SELECT s.product_id::uuid, s.day::date, s.views, s.clicks
+		FROM unnest($1::text[], $2::text[], $3::int[], $4::int[]) AS s(product_id, day, views, clicks)
+		WHERE EXISTS (SELECT 1 FROM products p WHERE p.id = s.product_id::uuid)
+		ON CONFLICT (product_id, day) DO UPDATE SET
+			views = product_stats_daily.views + EXCLUDED.views,
+			clicks = product_stats_daily.clicks + EXCLUDED.clicks
+	`, ids, days, views, clicks)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE products p SET
+			view_count = p.view_count + s.views,
+			click_count = p.click_count + s.clicks
+		FROM (
+			SELECT product_id::uuid AS id, SUM(views) AS views, SUM(clicks) AS clicks
+			FROM unnest($1::text[], $2::int[], $3::int[]) AS s(product_id, views, clicks)
+			GROUP BY 1
+		) s
+		WHERE p.id = s.id
+	`, ids, views, clicks)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
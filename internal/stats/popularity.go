@@ -0,0 +1,33 @@
+package stats
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Popularita = súčet (zobrazenia + 5 × preklíky) za posledné dni, staršie dni
+// majú exponenciálne menšiu váhu
+const (
+	popularityWindowDays = 30
+	popularityDecayDays  = 7.0
+	clickWeight          = 5
+)
+
+// RefreshPopularity prepočíta products.popularity_score z denných štatistík
+func RefreshPopularity(ctx context.Context, db *pgxpool.Pool) error {
+	_, err := db.Exec(ctx, `
+		WITH scores AS (
+			SELECT product_id,
+				SUM((views + $3 * clicks) * exp(-(CURRENT_DATE - day) / $2::float8)) AS score
+			FROM product_stats_daily
+			WHERE day > CURRENT_DATE - $1::int
+			GROUP BY product_id
+		)
+		UPDATE products p SET popularity_score = COALESCE(s.score, 0)
+		FROM products p2
+		LEFT JOIN scores s ON s.product_id = p2.id
+		WHERE p.id = p2.id AND p.popularity_score IS DISTINCT FROM COALESCE(s.score, 0)
+	`, popularityWindowDays, popularityDecayDays, clickWeight)
+	return err
+}
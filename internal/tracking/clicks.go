@@ -0,0 +1,38 @@
+package tracking
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Click - Jeden preklik do obchodu
+type Click struct {
+	ID            string
+	ProductID     string
+	OfferID       *string
+	FeedID        *string
+	TargetURL     string
+	Referrer      string
+	UserAgentHash string
+	SessionID     string
+	FilterReason  string // traffic.Reason*, empty = human click
+}
+
+// Record uloží preklik a pri ľudskom preklike zvýši počítadlo ponuky; počítadlo
+// produktu zapisuje dávkovo stats.Counter. Filtrované prekliky sa ukladajú tiež,
+// aby konverzia k ich click_id nezanikla
+func Record(ctx context.Context, db *pgxpool.Pool, c Click) error {
+	_, err := db.Exec(ctx, `
+		INSERT INTO clicks (id, product_id, offer_id, feed_id, target_url, referrer, user_agent_hash, session_id, filter_reason)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''))
+	`, c.ID, c.ProductID, c.OfferID, c.FeedID, c.TargetURL, c.Referrer, c.UserAgentHash, c.SessionID, c.FilterReason)
+	if err != nil {
+		return err
+	}
+
+	if c.OfferID != nil && c.FilterReason == "" {
+		_, err = db.Exec(ctx, "UPDATE product_offers SET click_count = click_count + 1 WHERE id = $1", *c.OfferID)
+	}
+	return err
+}
@@ -0,0 +1,97 @@
+package tracking
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Stavy konverzie
+const (
+	StatusPending  = "pending"
+	StatusApproved = "approved"
+	StatusRejected = "rejected"
+)
+
+// ErrUnknownClick - postback odkazuje na click_id, ktorý sme nevygenerovali
+var ErrUnknownClick = errors.New("unknown click_id")
+
+// statusAliases - názvy stavov, ktoré používajú siete
+var statusAliases = map[string]string{
+	"":          StatusPending,
+	"pending":   StatusPending,
+	"open":      StatusPending,
+	"new":       StatusPending,
+	"approved":  StatusApproved,
+	"accepted":  StatusApproved,
+	"confirmed": StatusApproved,
+	"paid":      StatusApproved,
+	"rejected":  StatusRejected,
+	"declined":  StatusRejected,
+	"cancelled": StatusRejected,
+	"canceled":  StatusRejected,
+}
+
+// NormalizeStatus prevedie stav zo siete na pending / approved / rejected
+func NormalizeStatus(status string) (string, bool) {
+	normalized, ok := statusAliases[strings.ToLower(strings.TrimSpace(status))]
+	return normalized, ok
+}
+
+// Conversion - Údaje z postbacku
+type Conversion struct {
+	ClickID    string
+	OrderID    string
+	Amount     float64
+	Commission float64
+	Currency   string
+	Status     string
+	Payload    map[string]string
+}
+
+// SaveConversion uloží konverziu k prekliku; opakovaný postback tej istej objednávky
+// aktualizuje sumy a stav. Vráti true, ak bola konverzia nová.
+func SaveConversion(ctx context.Context, db *pgxpool.Pool, c Conversion) (bool, error) {
+	if c.Currency == "" {
+		c.Currency = "EUR"
+	}
+
+	var created bool
+	err := db.QueryRow(ctx, `
+		INSERT INTO conversions (click_id, order_id, amount, commission, currency, status,
+			product_id, feed_id, payload)
+		SELECT c.id, $2, $3, $4, $5, $6, c.product_id, c.feed_id, $7
+		FROM clicks c WHERE c.id::text = $1
+		ON CONFLICT (click_id, order_id) DO UPDATE SET
+			amount = EXCLUDED.amount, commission = EXCLUDED.commission,
+			currency = EXCLUDED.currency, status = EXCLUDED.status,
+			payload = EXCLUDED.payload, updated_at = NOW()
+		RETURNING xmax = 0
+	`, c.ClickID, c.OrderID, c.Amount, c.Commission, c.Currency, c.Status, c.Payload).Scan(&created)
+	if err == pgx.ErrNoRows {
+		return false, ErrUnknownClick
+	}
+	return created, err
+}
+
+// Sign vráti hex HMAC-SHA256 podpis payloadu
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature porovná podpis v konštantnom čase
+func VerifySignature(secret string, payload []byte, signature string) bool {
+	if secret == "" || signature == "" {
+		return false
+	}
+	expected := Sign(secret, payload)
+	return hmac.Equal([]byte(expected), []byte(strings.ToLower(strings.TrimPrefix(signature, "sha256="))))
+}
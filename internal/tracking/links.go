@@ -0,0 +1,112 @@
+package tracking
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+
+	"eshopbuilder/internal/models"
+)
+
+// Networks - Predvolené deep-link šablóny affiliate sietí
+var Networks = map[string]string{
+	"dognet": "https://login.dognet.sk/scripts/click.php?a_aid={publisher_id}&a_bid={advertiser_id}&data1={click_id}&desturl={url_encoded}",
+	"cj":     "https://www.anrdoezrs.net/links/{publisher_id}/type/dlg/sid/{click_id}/{url}",
+	"awin":   "https://www.awin1.com/cread.php?awinmid={advertiser_id}&awinaffid={publisher_id}&clickref={click_id}&ued={url_encoded}",
+	"ehub":   "https://ehub.cz/system/scripts/click.php?a_aid={publisher_id}&a_bid={advertiser_id}&data1={click_id}&desturl={url_encoded}",
+}
+
+// Placeholders - Premenné dostupné v šablónach
+var Placeholders = []string{
+	"url", "url_encoded", "ean", "sku", "feed_id", "click_id", "product_id", "slug",
+	"publisher_id", "advertiser_id",
+}
+
+// NetworkNames vráti podporované siete zoradené podľa názvu
+func NetworkNames() []string {
+	names := make([]string, 0, len(Networks))
+	for name := range Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateLink overí nastavenie deep-linku feedu
+func ValidateLink(link *models.AffiliateLink) error {
+	if link == nil {
+		return nil
+	}
+	if link.Network != "" && Networks[link.Network] == "" {
+		return errors.New("unknown affiliate network " + link.Network)
+	}
+
+	template := linkTemplate(link)
+	if template == "" {
+		return errors.New("affiliate_link needs a network or a template")
+	}
+	if !strings.Contains(template, "{url}") && !strings.Contains(template, "{url_encoded}") {
+		return errors.New("affiliate link template must contain {url} or {url_encoded}")
+	}
+	if strings.Contains(template, "{publisher_id}") && link.PublisherID == "" {
+		return errors.New("affiliate_link.publisher_id is required")
+	}
+	if strings.Contains(template, "{advertiser_id}") && link.AdvertiserID == "" {
+		return errors.New("affiliate_link.advertiser_id is required")
+	}
+
+	// The template itself has to produce a valid link
+	sample := make(map[string]string)
+	for _, name := range Placeholders {
+		sample[name] = "x"
+	}
+	_, err := checkURL(Expand(template, sample))
+	return err
+}
+
+// Wrap obalí URL produktu do deep-linku siete; bez nastavenia vráti URL nezmenenú
+func Wrap(link *models.AffiliateLink, productURL string, vars map[string]string) (string, error) {
+	template := linkTemplate(link)
+	if template == "" {
+		return productURL, nil
+	}
+
+	all := map[string]string{
+		"url":           productURL,
+		"url_encoded":   url.QueryEscape(productURL),
+		"publisher_id":  url.QueryEscape(link.PublisherID),
+		"advertiser_id": url.QueryEscape(link.AdvertiserID),
+	}
+	for name, value := range vars {
+		all[name] = url.QueryEscape(value)
+	}
+
+	u, err := checkURL(Expand(template, all))
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+func linkTemplate(link *models.AffiliateLink) string {
+	if link == nil {
+		return ""
+	}
+	if link.Template != "" {
+		return link.Template
+	}
+	return Networks[link.Network]
+}
+
+// checkURL - povolené sú len absolútne http(s) URL
+func checkURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, errors.New("affiliate URL must be an absolute http(s) URL")
+	}
+	return u, nil
+}
@@ -0,0 +1,138 @@
+package tracking
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+
+	"eshopbuilder/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrNotFound - produkt neexistuje, nie je aktívny alebo nemá affiliate URL
+var ErrNotFound = errors.New("click target not found")
+
+// Target - Cieľ prekliku: ponuka produktu a nastavenia jej feedu
+type Target struct {
+	ProductID string
+	Slug      string
+	OfferID   *string
+	FeedID    *string
+	EAN       *string
+	SKU       *string
+	URL       string
+	Settings  models.FeedSettings
+}
+
+// Resolve nájde affiliate URL produktu; bez offerID (alebo pri neznámej ponuke)
+// použije najlepšiu ponuku a nakoniec URL samotného produktu
+func Resolve(ctx context.Context, db *pgxpool.Pool, slug, offerID string) (*Target, error) {
+	var t Target
+	var affiliateURL *string
+	var settings []byte
+
+	err := db.QueryRow(ctx, `
+		SELECT p.id, p.slug, o.id, COALESCE(o.feed_id, p.feed_id),
+			COALESCE(NULLIF(o.ean, ''), p.ean), COALESCE(NULLIF(o.sku, ''), p.sku),
+			COALESCE(NULLIF(o.affiliate_url, ''), p.affiliate_url),
+			COALESCE(f.settings, '{}'::jsonb)
+		FROM products p
+		LEFT JOIN LATERAL (
+			SELECT po.id, po.feed_id, po.affiliate_url, po.ean, po.sku
+			FROM product_offers po
+			WHERE po.product_id = p.id AND po.is_active = true
+				AND (po.id::text = $2 OR po.id = p.best_offer_id)
+			ORDER BY po.id::text = $2 DESC
+			LIMIT 1
+		) o ON true
+		LEFT JOIN feeds f ON f.id = COALESCE(o.feed_id, p.feed_id)
+		WHERE p.slug = $1 AND p.is_active = true
+	`, slug, offerID).Scan(&t.ProductID, &t.Slug, &t.OfferID, &t.FeedID, &t.EAN, &t.SKU, &affiliateURL, &settings)
+	if err == pgx.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if affiliateURL == nil || strings.TrimSpace(*affiliateURL) == "" {
+		return nil, ErrNotFound
+	}
+
+	t.URL = strings.TrimSpace(*affiliateURL)
+	json.Unmarshal(settings, &t.Settings)
+	return &t, nil
+}
+
+// ParseParams načíta parametre v tvare query stringu ("utm_source=eshop&utm_medium=affiliate")
+func ParseParams(raw string) map[string]string {
+	params := make(map[string]string)
+	values, _ := url.ParseQuery(raw)
+	for key := range values {
+		params[key] = values.Get(key)
+	}
+	return params
+}
+
+// AppendParams pridá parametre k URL (existujúce prepíše); hodnoty môžu obsahovať
+// premenné {click_id}, {product_id}, {feed_id}, {slug}, {ean}, {sku}. Povolené sú len http(s) URL.
+func AppendParams(rawURL string, params map[string]string, vars map[string]string) (string, error) {
+	u, err := checkURL(rawURL)
+	if err != nil {
+		return "", err
+	}
+	if len(params) == 0 {
+		return u.String(), nil
+	}
+
+	// Keep the order stable so the same click always produces the same URL
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Existing parameters are kept byte for byte, networks often rely on their exact encoding
+	parts := []string{}
+	for _, part := range strings.Split(u.RawQuery, "&") {
+		key, _, _ := strings.Cut(part, "=")
+		if name, err := url.QueryUnescape(key); part == "" || err == nil && params[name] != "" {
+			continue
+		}
+		parts = append(parts, part)
+	}
+	for _, key := range keys {
+		if value := Expand(params[key], vars); value != "" {
+			parts = append(parts, url.QueryEscape(key)+"="+url.QueryEscape(value))
+		}
+	}
+	u.RawQuery = strings.Join(parts, "&")
+	return u.String(), nil
+}
+
+// Expand nahradí {premenné} v texte
+func Expand(text string, vars map[string]string) string {
+	if !strings.Contains(text, "{") {
+		return text
+	}
+	pairs := make([]string, 0, len(vars)*2)
+	for name, value := range vars {
+		pairs = append(pairs, "{"+name+"}", value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// HashUserAgent - user agent sa neukladá, len jeho SHA-256
+func HashUserAgent(userAgent string) string {
+	if userAgent == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(userAgent))
+	return hex.EncodeToString(sum[:])
+}
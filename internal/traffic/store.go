@@ -0,0 +1,159 @@
+package traffic
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// maxUserAgent - dlhšie user agenty sa orežú
+const maxUserAgent = 500
+
+// maxPending - strop rôznych udalostí v buffri; pri náleve crawlerov sa ďalšie
+// do najbližšieho zápisu zahodia a len spočítajú
+const maxPending = 10000
+
+// filteredKey - udalosti s rovnakým kľúčom sa zapíšu jedným riadkom s počtom hits
+type filteredKey struct {
+	kind      string
+	reason    string
+	productID string
+	feedID    string
+	ipHash    string
+	userAgent string
+	sessionID string
+	referrer  string
+}
+
+// Recorder - Zbiera odfiltrované udalosti v pamäti a periodicky ich zapisuje
+// do filtered_traffic jedným dávkovým dotazom
+type Recorder struct {
+	db       *pgxpool.Pool
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[filteredKey]int
+	dropped int
+}
+
+func NewRecorder(db *pgxpool.Pool, interval time.Duration) *Recorder {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &Recorder{
+		db:       db,
+		interval: interval,
+		pending:  make(map[filteredKey]int),
+	}
+}
+
+// Record započíta odfiltrovanú udalosť; IP sa ukladá len ako hash
+func (r *Recorder) Record(e Event, reason string) {
+	userAgent := e.UserAgent
+	if len(userAgent) > maxUserAgent {
+		userAgent = strings.ToValidUTF8(userAgent[:maxUserAgent], "")
+	}
+	key := filteredKey{
+		kind:      e.Kind,
+		reason:    reason,
+		productID: e.ProductID,
+		ipHash:    hashIP(e.IP),
+		userAgent: userAgent,
+		sessionID: e.SessionID,
+		referrer:  e.Referrer,
+	}
+	if e.FeedID != nil {
+		key.feedID = *e.FeedID
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.pending[key]; !ok && len(r.pending) >= maxPending {
+		r.dropped++
+		return
+	}
+	r.pending[key]++
+}
+
+// Run zapisuje udalosti každý interval; po zrušení ctx vykoná posledný zápis a skončí
+func (r *Recorder) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if err := r.Flush(flushCtx); err != nil {
+				log.Printf("Filtered traffic flush failed: %v", err)
+			}
+			cancel()
+			return
+		case <-ticker.C:
+			if err := r.Flush(ctx); err != nil {
+				log.Printf("Filtered traffic flush failed: %v", err)
+			}
+		}
+	}
+}
+
+// Flush zapíše nazbierané udalosti; pri chybe sa zahodia (ide len o štatistiku)
+func (r *Recorder) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	pending := r.pending
+	dropped := r.dropped
+	r.pending = make(map[filteredKey]int)
+	r.dropped = 0
+	r.mu.Unlock()
+
+	if dropped > 0 {
+		log.Printf("Filtered traffic buffer full, %d events not recorded", dropped)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	n := len(pending)
+	kinds, reasons := make([]string, 0, n), make([]string, 0, n)
+	products, feeds := make([]string, 0, n), make([]string, 0, n)
+	ips, agents := make([]string, 0, n), make([]string, 0, n)
+	sessions, referrers := make([]string, 0, n), make([]string, 0, n)
+	hits := make([]int, 0, n)
+	for key, count := range pending {
+		kinds = append(kinds, key.kind)
+		reasons = append(reasons, key.reason)
+		products = append(products, key.productID)
+		feeds = append(feeds, key.feedID)
+		ips = append(ips, key.ipHash)
+		agents = append(agents, key.userAgent)
+		sessions = append(sessions, key.sessionID)
+		referrers = append(referrers, key.referrer)
+		hits = append(hits, count)
+	}
+
+	// Products and feeds deleted since the event are stored as NULL
+	_, err := r.db.Exec(ctx, `
+		INSERT INTO filtered_traffic (kind, reason, product_id, feed_id, ip_hash, user_agent, session_id, referrer, hits)
+		SELECT s.kind, s.reason, p.id, f.id, NULLIF(s.ip_hash, ''), NULLIF(s.user_agent, ''),
+			NULLIF(s.session_id, ''), NULLIF(s.referrer, ''), s.hits
+		FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::text[], $6::text[], $7::text[], $8::text[], $9::int[])
+			AS s(kind, reason, product_id, feed_id, ip_hash, user_agent, session_id, referrer, hits)
+		LEFT JOIN products p ON p.id = NULLIF(s.product_id, '')::uuid
+		LEFT JOIN feeds f ON f.id = NULLIF(s.feed_id, '')::uuid
+	`, kinds, reasons, products, feeds, ips, agents, sessions, referrers, hits)
+	return err
+}
+
+func hashIP(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(ip))
+	return hex.EncodeToString(sum[:])
+}
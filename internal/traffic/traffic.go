@@ -0,0 +1,241 @@
+package traffic
+
+import (
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Druhy udalostí
+const (
+	KindView  = "view"
+	KindClick = "click"
+)
+
+// Dôvody odfiltrovania
+const (
+	ReasonIgnoredIP = "ignored_ip"
+	ReasonBot       = "bot"
+	ReasonRateLimit = "rate_limit"
+	ReasonDuplicate = "duplicate"
+)
+
+// botAgents - časti user agentov crawlerov, monitoringu a HTTP knižníc (malými písmenami)
+var botAgents = []string{
+	"bot", "crawl", "spider", "slurp", "scrape", "fetch", "preview", "monitor", "check",
+	"headless", "phantomjs", "selenium", "lighthouse", "pingdom", "uptime", "kube-probe",
+	"elb-healthchecker", "go-http-client", "curl", "wget", "python-requests", "python-urllib",
+	"java/", "okhttp", "axios", "node-fetch", "libwww", "httpclient", "facebookexternalhit",
+}
+
+// Config - Nastavenie klasifikácie
+type Config struct {
+	BotAgents       []string      // extra user agent fragments
+	IgnoredIPs      []string      // IPs or CIDRs (office, monitoring)
+	MaxPerMinute    int           // events per IP and minute, 0 = unlimited
+	DuplicateWindow time.Duration // same event from the same visitor within the window
+	TrustProxy      bool          // take the client IP from the last X-Forwarded-For hop
+	TrustRealIP     bool          // take the client IP from X-Real-IP (set by the proxy)
+}
+
+// Event - Zobrazenie alebo preklik na klasifikáciu
+type Event struct {
+	Kind      string
+	ProductID string
+	FeedID    *string
+	IP        string
+	UserAgent string
+	SessionID string
+	Referrer  string
+}
+
+// Verdict - Výsledok klasifikácie, prázdny Reason = ľudská návšteva
+type Verdict struct {
+	Reason     string
+	PreviousID string // ID of the original event for duplicates
+}
+
+func (v Verdict) Human() bool {
+	return v.Reason == ""
+}
+
+// Classifier - Odlišuje ľudskú návštevnosť od botov a opakovaných udalostí
+type Classifier struct {
+	cfg     Config
+	agents  []string
+	ignored []*net.IPNet
+
+	mu        sync.Mutex
+	rates     map[string]*rate
+	seen      map[string]seenEvent
+	lastSweep time.Time
+}
+
+type rate struct {
+	minute time.Time
+	count  int
+}
+
+type seenEvent struct {
+	at time.Time
+	id string
+}
+
+func New(cfg Config) *Classifier {
+	c := &Classifier{
+		cfg:       cfg,
+		agents:    append([]string{}, botAgents...),
+		rates:     make(map[string]*rate),
+		seen:      make(map[string]seenEvent),
+		lastSweep: time.Now(),
+	}
+	for _, agent := range cfg.BotAgents {
+		if agent = strings.ToLower(strings.TrimSpace(agent)); agent != "" {
+			c.agents = append(c.agents, agent)
+		}
+	}
+	for _, entry := range cfg.IgnoredIPs {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, "/") {
+			if strings.Contains(entry, ":") {
+				entry += "/128"
+			} else {
+				entry += "/32"
+			}
+		}
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			c.ignored = append(c.ignored, network)
+		}
+	}
+	return c
+}
+
+// Classify posúdi udalosť; id ľudskej udalosti si zapamätá, aby duplikát
+// v okne DuplicateWindow vrátil pôvodné ID
+func (c *Classifier) Classify(e Event, id string) Verdict {
+	if c.isIgnored(e.IP) {
+		return Verdict{Reason: ReasonIgnoredIP}
+	}
+	if IsBot(e.UserAgent, c.agents) {
+		return Verdict{Reason: ReasonBot}
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sweep(now)
+
+	// Every request counts towards the rate, duplicates included
+	if c.cfg.MaxPerMinute > 0 && e.IP != "" {
+		minute := now.Truncate(time.Minute)
+		r := c.rates[e.IP]
+		if r == nil || !r.minute.Equal(minute) {
+			r = &rate{minute: minute}
+			c.rates[e.IP] = r
+		}
+		r.count++
+		if r.count > c.cfg.MaxPerMinute {
+			return Verdict{Reason: ReasonRateLimit}
+		}
+	}
+
+	if c.cfg.DuplicateWindow > 0 {
+		key := e.Kind + "|" + e.ProductID + "|" + visitorKey(e)
+		if e.FeedID != nil {
+			key += "|" + *e.FeedID
+		}
+		if prev, ok := c.seen[key]; ok && now.Sub(prev.at) < c.cfg.DuplicateWindow {
+			return Verdict{Reason: ReasonDuplicate, PreviousID: prev.id}
+		}
+		c.seen[key] = seenEvent{at: now, id: id}
+	}
+
+	return Verdict{}
+}
+
+// ClientIP vráti IP návštevníka; hlavičky proxy len pri TrustProxy
+func (c *Classifier) ClientIP(r *http.Request) string {
+	return ClientIP(r, c.cfg.TrustProxy, c.cfg.TrustRealIP)
+}
+
+// ClientIP - IP klienta z RemoteAddr, za proxy z X-Real-IP alebo X-Forwarded-For.
+// Z X-Forwarded-For sa berie posledná položka, ktorú pridala naša proxy; skoršie
+// položky posiela klient a dajú sa podvrhnúť. X-Real-IP sa číta len pri trustRealIP,
+// lebo bez proxy, ktorá ho vždy nastaví, ho posiela klient
+func ClientIP(r *http.Request, trustProxy, trustRealIP bool) string {
+	if trustRealIP {
+		if realIP := strings.TrimSpace(r.Header.Get("X-Real-IP")); realIP != "" {
+			return realIP
+		}
+	}
+	if trustProxy {
+		if forwarded := r.Header.Values("X-Forwarded-For"); len(forwarded) > 0 {
+			hops := strings.Split(forwarded[len(forwarded)-1], ",")
+			if last := strings.TrimSpace(hops[len(hops)-1]); last != "" {
+				return last
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// IsBot - prázdny user agent alebo obsahuje niektorý zo zoznamu
+func IsBot(userAgent string, agents []string) bool {
+	ua := strings.ToLower(strings.TrimSpace(userAgent))
+	if ua == "" {
+		return true
+	}
+	for _, agent := range agents {
+		if strings.Contains(ua, agent) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Classifier) isIgnored(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range c.ignored {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// visitorKey - session z cookie, inak IP + user agent
+func visitorKey(e Event) string {
+	if e.SessionID != "" {
+		return e.SessionID
+	}
+	return e.IP + "|" + e.UserAgent
+}
+
+// sweep raz za minútu zahodí staré záznamy (volá sa pod zámkom)
+func (c *Classifier) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < time.Minute {
+		return
+	}
+	c.lastSweep = now
+
+	minute := now.Truncate(time.Minute)
+	for ip, r := range c.rates {
+		if r.minute.Before(minute) {
+			delete(c.rates, ip)
+		}
+	}
+	for key, s := range c.seen {
+		if now.Sub(s.at) >= c.cfg.DuplicateWindow {
+			delete(c.seen, key)
+		}
+	}
+}